@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How long an arg's optionsCmd may take to list its choices
+const defaultOptionsCmdTimeout = 5 * time.Second
+
+// parseChoices splits the output of an optionsCmd into choices, one per line,
+// blank lines are skipped
+func parseChoices(output string) []string {
+	var choices []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			choices = append(choices, line)
+		}
+	}
+	return choices
+}
+
+// needsChoices reports whether any arg of option lists its values with a command
+func needsChoices(option Option) bool {
+	for _, arg := range option.Args {
+		if arg.OptionsCmd != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadChoices runs the optionsCmd of each arg that has one and returns the
+// choices by arg name. Args whose command fails or prints nothing are left
+// out, so they're typed in instead, and reported as errors.
+func loadChoices(ctx context.Context, run outputRunner, args []Arg) (map[string][]string, []error) {
+	choices := make(map[string][]string)
+	var errs []error
+	for _, arg := range args {
+		if arg.OptionsCmd == "" {
+			continue
+		}
+		cmdCtx, cancel := context.WithTimeout(ctx, defaultOptionsCmdTimeout)
+		output, err := run(cmdCtx, arg.OptionsCmd)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", arg.Name, err))
+			continue
+		}
+		if values := parseChoices(output); len(values) > 0 {
+			choices[arg.Name] = values
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %q printed no choices", arg.Name, arg.OptionsCmd))
+		}
+	}
+	return choices, errs
+}
+
+// choiceIndex is the choice to preselect, the default if it's one of them
+func choiceIndex(choices []string, value string) int {
+	for i, choice := range choices {
+		if choice == value {
+			return i
+		}
+	}
+	return 0
+}
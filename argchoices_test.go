@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseChoices(t *testing.T) {
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{"web1\nweb2\n", []string{"web1", "web2"}},
+		{"  web1 \r\n\n\tdb\n\n", []string{"web1", "db"}},
+		{"", nil},
+		{"\n \n", nil},
+	}
+	for _, tt := range tests {
+		if got := parseChoices(tt.output); !slices.Equal(got, tt.want) {
+			t.Errorf("parseChoices(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestPickedChoiceIsSubstituted(t *testing.T) {
+	option := Option{
+		Title:   "SSH",
+		Command: "ssh ${1:host} -p ${2:port}",
+		Args: []Arg{
+			{Name: "host", OptionsCmd: "awk '/^Host /{print $2}' ~/.ssh/config"},
+			{Name: "port"},
+			{Name: "user", OptionsCmd: "broken"},
+		},
+	}
+	run := func(ctx context.Context, command string) (string, error) {
+		if command == "broken" {
+			return "", errors.New("exit status 1")
+		}
+		return "web1\nweb2\n\ndb\n", nil
+	}
+
+	choices, errs := loadChoices(context.Background(), run, option.Args)
+	if !slices.Equal(choices["host"], []string{"web1", "web2", "db"}) {
+		t.Errorf("host choices = %q, want web1, web2 and db", choices["host"])
+	}
+	if _, ok := choices["port"]; ok {
+		t.Errorf("port has choices without an optionsCmd")
+	}
+	if _, ok := choices["user"]; ok || len(errs) != 1 {
+		t.Errorf("a failing optionsCmd gave choices %q and errors %v, want it typed in and one error", choices["user"], errs)
+	}
+
+	// The last value is preselected when it's still a choice
+	if got := choiceIndex(choices["host"], "db"); got != 2 {
+		t.Errorf("choiceIndex(db) = %d, want 2", got)
+	}
+	if got := choiceIndex(choices["host"], "gone"); got != 0 {
+		t.Errorf("choiceIndex(gone) = %d, want the first choice", got)
+	}
+
+	parameters := parseParameters(option.Command)
+	values, _, _, err := argumentValues(option, parameters, []string{choices["host"][1], "2222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fillParameters(option.Command, parameters, values); got != "ssh web2 -p 2222" {
+		t.Errorf("command = %q, want the picked host substituted", got)
+	}
+}
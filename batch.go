@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// batch is the options marked with Space to run together, in the order they
+// were marked. Options are told apart by their path, so marks stay while
+// moving between menus.
+type batch []Option
+
+// canMark reports whether option can run as part of a batch: a command that
+// needs no arguments, file to edit or typed phrase
+func canMark(option Option) bool {
+	return isRunnable(option) && !opensOnEnter(option) && option.EditFile == "" &&
+		option.ConfirmPhrase == "" && len(parseParameters(option.Command)) == 0
+}
+
+// Toggle marks option, or unmarks it when it's marked already, and reports
+// whether it's marked now
+func (b *batch) Toggle(option Option) bool {
+	path := joinPath(option.Path, option.Title)
+	for i, marked := range *b {
+		if joinPath(marked.Path, marked.Title) == path {
+			*b = append((*b)[:i], (*b)[i+1:]...)
+			return false
+		}
+	}
+	*b = append(*b, option)
+	return true
+}
+
+// Has reports whether option is marked
+func (b batch) Has(option Option) bool {
+	path := joinPath(option.Path, option.Title)
+	for _, marked := range b {
+		if joinPath(marked.Path, marked.Title) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// expanded is a copy of the batch with each command as it will run
+func (b batch) expanded(expand func(string) string) batch {
+	commands := make(batch, len(b))
+	for i, option := range b {
+		option.Command = expand(option.Command)
+		commands[i] = option
+	}
+	return commands
+}
+
+// batchSummary lists every option of the batch with the command it runs, for
+// the dialog asking before any of them runs
+func batchSummary(msg messages, b batch) string {
+	lines := []string{msg.get("confirmBatch", len(b)), ""}
+	for i, option := range b {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, tview.Escape(joinPath(option.Path, option.Title))))
+		lines = append(lines, "   [gray]"+tview.Escape(option.Command)+"[-]")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// batchDialog shows summary above Run and Cancel buttons, the arrow and page
+// keys scroll the summary. Cancel and Escape call done with false.
+func batchDialog(summary string, msg messages, done func(confirmed bool)) *tview.Flex {
+	text := tview.NewTextView().SetDynamicColors(true).SetText(summary)
+	form := tview.NewForm().
+		AddButton(msg.get("run"), func() { done(true) }).
+		AddButton(msg.get("cancel"), func() { done(false) }).
+		SetButtonsAlign(tview.AlignCenter).
+		SetCancelFunc(func() { done(false) })
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			text.InputHandler()(event, func(tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+	dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 0, 1, false).
+		AddItem(form, 3, 0, true)
+	dialog.SetBorder(true)
+	return dialog
+}
+
+// runBatch runs every option of the batch in order, also after one failed,
+// and returns the last status that wasn't 0
+func runBatch(b batch, run func(Option) int) int {
+	status := 0
+	for _, option := range b {
+		if code := run(option); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestCanMark(t *testing.T) {
+	tests := []struct {
+		option Option
+		want   bool
+	}{
+		{Option{Title: "Top", Command: "top"}, true},
+		{Option{Title: "Git", Children: []Option{{Title: "Log", Command: "git log"}}}, false},
+		{Option{Title: "Deploy", Command: "make deploy", Disabled: true}, false},
+		{Option{Title: "Greet", Command: "echo hello ${1:name}"}, false},
+		{Option{Title: "Drop", Command: "dropdb prod", ConfirmPhrase: "prod"}, false},
+		{Option{Title: "Hosts", EditFile: "/etc/hosts"}, false},
+		{Option{Title: "Empty"}, false},
+	}
+	for _, tt := range tests {
+		if got := canMark(tt.option); got != tt.want {
+			t.Errorf("canMark(%s) = %t, want %t", tt.option.Title, got, tt.want)
+		}
+	}
+}
+
+func TestBatchMarks(t *testing.T) {
+	var b batch
+	up := Option{Title: "Up", Path: "Docker", Command: "docker compose up"}
+	otherUp := Option{Title: "Up", Path: "Vagrant", Command: "vagrant up"}
+
+	if !b.Toggle(up) || !b.Toggle(otherUp) {
+		t.Fatal("Toggle() didn't mark the options")
+	}
+	if !b.Has(Option{Title: "Up", Path: "Docker"}) {
+		t.Error("Has() didn't find Docker/Up by its path")
+	}
+	if b.Toggle(up) {
+		t.Error("Toggle() on a marked option marked it again")
+	}
+	if b.Has(up) || !b.Has(otherUp) {
+		t.Errorf("after unmarking Docker/Up marked = %q, want only Vagrant/Up", titles(b))
+	}
+}
+
+func TestBatchSummary(t *testing.T) {
+	b := batch{
+		{Title: "Pull", Path: "Git", Command: "git pull"},
+		{Title: "Build", Command: "make $TARGET"},
+	}
+	commands := b.expanded(func(command string) string {
+		return strings.ReplaceAll(command, "$TARGET", "[all]")
+	})
+	want := "Run these 2 commands?\n\n" +
+		"1. Git/Pull\n   [gray]git pull[-]\n" +
+		"2. Build\n   [gray]make [all[][-]"
+	if got := batchSummary(messages{}, commands); got != want {
+		t.Errorf("batchSummary() = %q, want %q", got, want)
+	}
+	if b[1].Command != "make $TARGET" {
+		t.Error("expanded() changed the marked options")
+	}
+}
+
+func TestBatchDialogGatesTheRun(t *testing.T) {
+	b := batch{{Title: "Ok", Command: "true"}, {Title: "Fail", Command: "false"}, {Title: "Last", Command: "true"}}
+	press := func(p tview.Primitive, key tcell.Key) {
+		p.InputHandler()(tcell.NewEventKey(key, 0, tcell.ModNone), func(tview.Primitive) {})
+	}
+
+	tests := []struct {
+		name    string
+		answer  func(form *tview.Form)
+		wantRan []string
+	}{
+		{"run", func(form *tview.Form) { press(form.GetButton(0), tcell.KeyEnter) }, []string{"Ok", "Fail", "Last"}},
+		{"cancel", func(form *tview.Form) { press(form.GetButton(1), tcell.KeyEnter) }, nil},
+		{"escape", func(form *tview.Form) { press(form.GetButton(0), tcell.KeyEscape) }, nil},
+	}
+	for _, tt := range tests {
+		var ran []string
+		status := -1
+		dialog := batchDialog(batchSummary(messages{}, b), messages{}, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			status = runBatch(b, func(option Option) int {
+				ran = append(ran, option.Title)
+				if option.Title == "Fail" {
+					return 3
+				}
+				return 0
+			})
+		})
+		if ran != nil {
+			t.Fatalf("%s: ran %q before anything was answered", tt.name, ran)
+		}
+		tt.answer(dialog.GetItem(1).(*tview.Form))
+		if !slices.Equal(ran, tt.wantRan) {
+			t.Errorf("%s: ran %q, want %q", tt.name, ran, tt.wantRan)
+		}
+		// A failure doesn't stop the rest, its status is returned
+		if tt.wantRan != nil && status != 3 {
+			t.Errorf("%s: runBatch() = %d, want 3", tt.name, status)
+		}
+	}
+}
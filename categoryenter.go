@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Values of the config's categoryEnter
+const (
+	categoryDescend = "descend"
+	categoryPreview = "preview"
+)
+
+// checkCategoryEnter validates the config's categoryEnter, empty means descend
+func checkCategoryEnter(mode string) error {
+	switch mode {
+	case "", categoryDescend, categoryPreview:
+		return nil
+	}
+	return fmt.Errorf("categoryEnter has to be %q or %q, got %q", categoryDescend, categoryPreview, mode)
+}
+
+// categoryKeyOpens reports whether key opens the selected category, Enter
+// does unless categoryEnter is preview, then it's Right and Enter previews
+func categoryKeyOpens(mode string, key tcell.Key) bool {
+	switch key {
+	case tcell.KeyEnter:
+		return mode != categoryPreview
+	case tcell.KeyRight:
+		return mode == categoryPreview
+	}
+	return false
+}
+
+// previewChildren lists the titles of a category's children for the info box,
+// categories among them marked like in the list
+func previewChildren(category Option) string {
+	var lines []string
+	for _, child := range category.Children {
+		if child.Separator {
+			continue
+		}
+		title := tview.Escape(child.Title)
+		if isCategory(child) {
+			title = "> " + title
+		}
+		lines = append(lines, "  "+title)
+	}
+	return strings.Join(lines, "\n")
+}
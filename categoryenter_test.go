@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCategoryEnter(t *testing.T) {
+	tests := []struct {
+		mode       string
+		key        tcell.Key
+		wantOpened bool
+	}{
+		{"", tcell.KeyEnter, true},
+		{"", tcell.KeyRight, false},
+		{categoryDescend, tcell.KeyEnter, true},
+		{categoryDescend, tcell.KeyRight, false},
+		{categoryPreview, tcell.KeyEnter, false},
+		{categoryPreview, tcell.KeyRight, true},
+		{categoryPreview, tcell.KeyLeft, false},
+	}
+	for _, tt := range tests {
+		if got := categoryKeyOpens(tt.mode, tt.key); got != tt.wantOpened {
+			t.Errorf("categoryKeyOpens(%q, %s) = %t, want %t", tt.mode, tcell.KeyNames[tt.key], got, tt.wantOpened)
+		}
+	}
+
+	if err := checkCategoryEnter("toggle"); err == nil {
+		t.Error("checkCategoryEnter(toggle) accepted an unknown mode")
+	}
+}
+
+func TestPreviewChildren(t *testing.T) {
+	category := Option{Title: "Git", Children: []Option{
+		{Title: "Log", Command: "git log"},
+		{Title: "---", Separator: true},
+		{Title: "Remotes", Children: []Option{{Title: "List", Command: "git remote -v"}}},
+		{Title: "[wip]", Command: "git stash"},
+	}}
+	want := "  Log\n  > Remotes\n  [wip[]"
+	if got := previewChildren(category); got != want {
+		t.Errorf("previewChildren() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// isBlankCommand reports whether command is empty or only whitespace, which
+// would hand the shell wrapper nothing to run
+func isBlankCommand(command string) bool {
+	return strings.TrimSpace(command) == ""
+}
+
+// isMissingCommand reports whether an option is a leaf with nothing to run,
+// usually a command that was forgotten
+func isMissingCommand(option Option) bool {
+	return isBlankCommand(option.Command) && option.EditFile == "" && option.Children == nil && option.ChildrenCmd == "" && !option.Separator
+}
+
+// isRunnable reports whether Enter runs the option's command, disabled
+// options are only shown
+func isRunnable(option Option) bool {
+	return !option.Disabled && !isBlankCommand(option.Command)
+}
+
+// checkOptions lists authoring mistakes in the tree by breadcrumb path: leaves
+// without a command, titles repeating a sibling's and unknown levels
+func checkOptions(options []Option, path string) []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, opt := range options {
+		if opt.Separator {
+			continue
+		}
+		optPath := joinPath(path, opt.Title)
+		if seen[opt.Title] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate title", optPath))
+		}
+		seen[opt.Title] = true
+		if isMissingCommand(opt) && !opt.Disabled {
+			problems = append(problems, fmt.Sprintf("%s: no command", optPath))
+		}
+		if opt.Level != "" && !isLevel(opt.Level) {
+			problems = append(problems, fmt.Sprintf("%s: unknown level %q", optPath, opt.Level))
+		}
+		problems = append(problems, checkOptions(opt.Children, optPath)...)
+	}
+	return problems
+}
+
+// optionNotes lists the maintenance notes in the tree by breadcrumb path
+func optionNotes(options []Option, path string) []string {
+	var notes []string
+	for _, opt := range options {
+		optPath := joinPath(path, opt.Title)
+		if opt.Note != "" {
+			notes = append(notes, fmt.Sprintf("%s: %s", optPath, opt.Note))
+		}
+		notes = append(notes, optionNotes(opt.Children, optPath)...)
+	}
+	return notes
+}
+
+// printCheck writes the problems found by checkOptions followed by the notes,
+// and reports whether there were no problems
+func printCheck(w io.Writer, problems []string, notes []string) bool {
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "No problems found")
+	}
+	if len(notes) > 0 {
+		fmt.Fprintln(w, "\nNotes:")
+		for _, note := range notes {
+			fmt.Fprintf(w, "  %s\n", note)
+		}
+	}
+	return len(problems) == 0
+}
+
+// debugDetails describes an option for the debug view: where it is, what it
+// runs as written and the notes left for maintainers
+func debugDetails(option Option, path string) string {
+	lines := []string{"Path: " + path}
+	if option.Command != "" {
+		lines = append(lines, "Command: "+option.Command)
+	}
+	if option.EditFile != "" {
+		lines = append(lines, "Edit file: "+option.EditFile)
+	}
+	if option.ChildrenCmd != "" {
+		lines = append(lines, "Children command: "+option.ChildrenCmd)
+	}
+	if option.When != "" {
+		lines = append(lines, "When: "+option.When)
+	}
+	if option.Note != "" {
+		lines = append(lines, "Note: "+option.Note)
+	}
+	if option.Source != "" {
+		lines = append(lines, "Source: "+option.Source)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckFlagsLeavesWithoutACommand(t *testing.T) {
+	options := []Option{
+		{Title: "Build", Command: "make"},
+		{Title: "Git", Children: []Option{
+			{Title: "Status", Command: "git status"},
+			{Title: "Push"},
+			{Title: "Fetch", Command: "   "},
+		}},
+		{Title: "Retired", Disabled: true},
+		{Title: "Profile", EditFile: "~/.profile"},
+		{Title: "Branches", ChildrenCmd: "git branch"},
+		{Title: "Tools", Separator: true},
+	}
+	want := []string{"Git/Push: no command", "Git/Fetch: no command"}
+	if got := checkOptions(options, ""); !slices.Equal(got, want) {
+		t.Errorf("checkOptions() = %q, want %q", got, want)
+	}
+}
+
+func TestLeavesWithoutACommandAreDimmedAndDoNothing(t *testing.T) {
+	tests := []struct {
+		option       Option
+		wantTitle    string
+		wantRunnable bool
+	}{
+		{Option{Title: "Push"}, "[gray]Push[-]", false},
+		{Option{Title: "Fetch", Command: "  "}, "[gray]Fetch[-]", false},
+		{Option{Title: "Status", Command: "git status"}, "Status", true},
+	}
+	for _, tt := range tests {
+		if got := displayTitle(tt.option); got != tt.wantTitle {
+			t.Errorf("displayTitle(%q) = %q, want %q", tt.option.Title, got, tt.wantTitle)
+		}
+		if got := isRunnable(tt.option); got != tt.wantRunnable {
+			t.Errorf("isRunnable(%q) = %t, want %t", tt.option.Title, got, tt.wantRunnable)
+		}
+	}
+}
+
+func TestNotesOnlyShowInCheckAndDebug(t *testing.T) {
+	options := []Option{
+		{Title: "Deploy", Details: "Ships the app", Command: "make deploy", Note: "Remove once CI deploys"},
+		{Title: "Git", Children: []Option{{Title: "Gc", Command: "git gc", Note: "Ask before running on the monorepo"}}},
+	}
+
+	var out bytes.Buffer
+	printCheck(&out, checkOptions(options, ""), optionNotes(options, ""))
+	want := "No problems found\n\nNotes:\n  Deploy: Remove once CI deploys\n  Git/Gc: Ask before running on the monorepo\n"
+	if out.String() != want {
+		t.Errorf("printCheck() printed %q, want %q", out.String(), want)
+	}
+	if got := debugDetails(options[0], "Deploy"); !strings.Contains(got, "Note: Remove once CI deploys") {
+		t.Errorf("debugDetails() = %q, want the note", got)
+	}
+
+	// The list and the details users see leave it out
+	rendered := []string{
+		displayTitle(options[0]),
+		secondaryText(options[0], false, nil),
+		secondaryText(options[0], true, func(command string) string { return command }),
+		templateDetails(options[0].Details, options[0]),
+	}
+	for _, text := range rendered {
+		if strings.Contains(text, "CI") {
+			t.Errorf("rendered %q shows the note", text)
+		}
+	}
+}
+
+func TestWhitespaceOnlyCommands(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"\t\n", true},
+		{" ", true},
+		{" ls ", false},
+	}
+	for _, tt := range tests {
+		if got := isBlankCommand(tt.command); got != tt.want {
+			t.Errorf("isBlankCommand(%q) = %t, want %t", tt.command, got, tt.want)
+		}
+	}
+
+	// --check reports them and --select refuses them instead of printing blanks for eval
+	blank := Option{Title: "Fetch", Command: "\t \n"}
+	if got := checkOptions([]Option{blank}, ""); !slices.Equal(got, []string{"Fetch: no command"}) {
+		t.Errorf("checkOptions() = %q, want Fetch reported", got)
+	}
+	if err := checkSelected(blank, "Fetch", blank.Command, nil, false); err == nil {
+		t.Error("checkSelected() accepted a whitespace-only command")
+	}
+	// An edited command that's only whitespace runs nothing
+	if _, ok := editCommand(Option{Title: "Top", Command: "top"}, "  \t"); ok {
+		t.Error("editCommand() kept a whitespace-only command")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// How long a childrenCmd may take to list a menu's options
+const defaultChildrenTimeout = 5 * time.Second
+
+// loadChildren runs a menu's childrenCmd, which prints the menu's options as JSON
+func loadChildren(ctx context.Context, run outputRunner, command string) ([]Option, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultChildrenTimeout)
+	defer cancel()
+
+	out, err := run(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	return parseOptions([]byte(out))
+}
+
+// preserveSelection finds the row of current showing the option of the
+// selected row index of previous, by title, and otherwise keeps the index
+// within the new rows on one that can be selected
+func preserveSelection(previous []listRow, index int, current []listRow) int {
+	if index >= 0 && index < len(previous) && previous[index].Option != nil {
+		if i, ok := defaultChildIndex(current, previous[index].Option.Title); ok {
+			return i
+		}
+	}
+	return max(0, selectableRow(current, max(0, min(index, len(current)-1)), 1))
+}
+
+// refreshEvery calls refresh from a background goroutine every interval until
+// the returned stop function is called or ctx is done, refresh gets a context
+// that is cancelled by stop so it can tell whether its result is still wanted
+func refreshEvery(ctx context.Context, interval time.Duration, refresh func(ctx context.Context)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh(ctx)
+			}
+		}
+	}()
+	return cancel
+}
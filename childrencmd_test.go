@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPreserveSelectionWithGroupedRows(t *testing.T) {
+	before := []Option{
+		{Title: "Logs", Command: "tail -f log"},
+		{Title: "Containers", Children: []Option{{Title: "web", Command: "docker ps"}}},
+		{Title: "Status", Command: "docker info"},
+	}
+	after := append([]Option{{Title: "Images", Children: []Option{{Title: "list", Command: "docker images"}}}}, before...)
+
+	previous := buildMenuRows(groupByKind(before, categoriesFirst), false)
+	current := buildMenuRows(groupByKind(after, categoriesFirst), false)
+	// Containers, separator, Logs, Status before; Images, Containers, separator, Logs, Status after
+	got := preserveSelection(previous, 3, current)
+	if got != 4 || current[got].Option.Title != "Status" {
+		t.Errorf("preserveSelection() = %d, want 4 (Status)", got)
+	}
+}
+
+func TestPreserveSelectionGoneOption(t *testing.T) {
+	previous := buildMenuRows([]Option{{Title: "a", Command: "a"}, {Title: "b", Command: "b"}}, false)
+	current := buildMenuRows([]Option{{Title: "---", Separator: true}, {Title: "c", Command: "c"}}, false)
+	if got := preserveSelection(previous, 0, current); got != 1 {
+		t.Errorf("preserveSelection() = %d, want 1, the nearest selectable row", got)
+	}
+	if got := preserveSelection(previous, 1, nil); got != 0 {
+		t.Errorf("preserveSelection() on no rows = %d, want 0", got)
+	}
+}
+
+func TestRefreshKeepsTheSelectedOption(t *testing.T) {
+	containers := func(output string) []Option {
+		options, err := loadChildren(context.Background(), func(ctx context.Context, command string) (string, error) {
+			return output, nil
+		}, "docker-menu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return options
+	}
+	previous := buildMenuRows(containers(`[{"title": "api", "command": "docker logs api"}, {"title": "web", "command": "docker logs web"}]`), false)
+
+	tests := []struct {
+		name   string
+		output string
+		index  int
+		want   string
+	}{
+		{"moved down", `[{"title": "db", "command": "docker logs db"}, {"title": "api", "command": "docker logs api"}, {"title": "web", "command": "docker logs web"}]`, 1, "web"},
+		{"moved up", `[{"title": "web", "command": "docker logs web"}]`, 1, "web"},
+		// A container that stopped leaves the selection where it was
+		{"gone", `[{"title": "db", "command": "docker logs db"}, {"title": "cache", "command": "docker logs cache"}]`, 1, "cache"},
+	}
+	for _, tt := range tests {
+		current := buildMenuRows(containers(tt.output), false)
+		got := preserveSelection(previous, tt.index, current)
+		if got < 0 || got >= len(current) || current[got].Option.Title != tt.want {
+			t.Errorf("%s: preserveSelection() = %d, want the row of %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshEvery(t *testing.T) {
+	refreshed := make(chan context.Context, 10)
+	stop := refreshEvery(context.Background(), 10*time.Millisecond, func(ctx context.Context) {
+		refreshed <- ctx
+	})
+
+	var ctx context.Context
+	for range 2 {
+		select {
+		case ctx = <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("not refreshed")
+		}
+	}
+	stop()
+	// Stopping cancels a refresh still running, so its result is dropped
+	if ctx.Err() == nil {
+		t.Error("refresh context not cancelled by stop")
+	}
+	time.Sleep(30 * time.Millisecond)
+	for len(refreshed) > 0 {
+		<-refreshed
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(refreshed) > 0 {
+		t.Error("still refreshing after stop")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// How long a chord waits for its next key before starting over
+const chordTimeout = time.Second
+
+// chord is a key sequence that runs an option from anywhere in the menu
+type chord struct {
+	Keys   []rune
+	Option Option
+}
+
+// parseChord splits a chord like "g b d" into its keys, each a single character
+func parseChord(s string) ([]rune, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty chord")
+	}
+	keys := make([]rune, len(fields))
+	for i, field := range fields {
+		if utf8.RuneCountInString(field) != 1 {
+			return nil, fmt.Errorf("chord %q: keys have to be single characters, got %q", s, field)
+		}
+		keys[i], _ = utf8.DecodeRuneInString(field)
+	}
+	return keys, nil
+}
+
+// formatChord writes keys the way they're configured, e.g. "g b d"
+func formatChord(keys []rune) string {
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = string(key)
+	}
+	return strings.Join(fields, " ")
+}
+
+// collectChords gathers the chords of every option that can be run. Chords
+// using a key of a global action, or starting like another chord or being its
+// start, would never fire and are returned as errors instead.
+func collectChords(options []Option, global map[string][]rune) ([]chord, []error) {
+	reserved := make(map[rune]string)
+	for action, keys := range global {
+		for _, key := range keys {
+			reserved[key] = action
+		}
+	}
+
+	var chords []chord
+	var errs []error
+	for _, opt := range flattenOptions(options) {
+		if opt.Chord == "" || opt.Disabled {
+			continue
+		}
+		path := joinPath(opt.Path, opt.Title)
+		keys, err := parseChord(opt.Chord)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", path, err))
+			continue
+		}
+		if err := chordConflict(keys, reserved, chords); err != nil {
+			errs = append(errs, fmt.Errorf("%s: chord %q %v", path, opt.Chord, err))
+			continue
+		}
+		chords = append(chords, chord{Keys: keys, Option: opt})
+	}
+	return chords, errs
+}
+
+// chordConflict reports why keys can't be used next to the chords collected so far
+func chordConflict(keys []rune, reserved map[rune]string, chords []chord) error {
+	for _, key := range keys {
+		if action, taken := reserved[key]; taken {
+			return fmt.Errorf("uses %q, the %s key", key, action)
+		}
+	}
+	for _, other := range chords {
+		if hasKeyPrefix(keys, other.Keys) || hasKeyPrefix(other.Keys, keys) {
+			return fmt.Errorf("clashes with %q of %s", formatChord(other.Keys), joinPath(other.Option.Path, other.Option.Title))
+		}
+	}
+	return nil
+}
+
+// hasKeyPrefix reports whether keys starts with prefix
+func hasKeyPrefix(keys []rune, prefix []rune) bool {
+	if len(prefix) > len(keys) {
+		return false
+	}
+	for i := range prefix {
+		if keys[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// chordMatcher follows the keys pressed so far against the chords, a pause
+// longer than Timeout between keys starts over
+type chordMatcher struct {
+	Chords  []chord
+	Timeout time.Duration
+
+	pending []rune
+	last    time.Time
+}
+
+// Press feeds a key pressed at now. It returns the chord and true once its
+// last key is pressed, the third result is whether the key was used by a chord
+// at all, keys that weren't should be handled as usual.
+func (m *chordMatcher) Press(key rune, now time.Time) (chord, bool, bool) {
+	if len(m.pending) > 0 && now.Sub(m.last) > m.Timeout {
+		m.pending = nil
+	}
+	keys := append(append([]rune(nil), m.pending...), key)
+
+	started := false
+	for _, c := range m.Chords {
+		if !hasKeyPrefix(c.Keys, keys) {
+			continue
+		}
+		if len(c.Keys) == len(keys) {
+			m.pending = nil
+			return c, true, true
+		}
+		started = true
+	}
+	if !started {
+		m.pending = nil
+		return chord{}, false, false
+	}
+	m.pending, m.last = keys, now
+	return chord{}, false, true
+}
+
+// Pending lists the chords that can still complete, for showing them while typing
+func (m *chordMatcher) Pending() []chord {
+	var matches []chord
+	for _, c := range m.Chords {
+		if len(m.pending) > 0 && hasKeyPrefix(c.Keys, m.pending) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// chordHints lists chords with the option each of them runs, one per line
+func chordHints(chords []chord) string {
+	var b strings.Builder
+	for _, c := range chords {
+		fmt.Fprintf(&b, "%s  %s\n", formatChord(c.Keys), joinPath(c.Option.Path, c.Option.Title))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
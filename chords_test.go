@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChordMatcherPress(t *testing.T) {
+	start := time.Now()
+	matcher := chordMatcher{
+		Chords: []chord{
+			{Keys: []rune("gbd"), Option: Option{Title: "Delete branch"}},
+			{Keys: []rune("gs"), Option: Option{Title: "Status"}},
+		},
+		Timeout: time.Second,
+	}
+
+	steps := []struct {
+		key   rune
+		after time.Duration
+		fired string
+		used  bool
+	}{
+		{'g', 0, "", true},
+		{'b', 100 * time.Millisecond, "", true},
+		{'d', 200 * time.Millisecond, "Delete branch", true},
+		// Keys no chord starts with are left alone
+		{'x', 300 * time.Millisecond, "", false},
+		{'g', 400 * time.Millisecond, "", true},
+		{'s', 500 * time.Millisecond, "Status", true},
+		// A pause longer than the timeout starts over
+		{'g', 600 * time.Millisecond, "", true},
+		{'s', 2 * time.Second, "", false},
+		// A wrong key drops what was typed
+		{'g', 2100 * time.Millisecond, "", true},
+		{'x', 2200 * time.Millisecond, "", false},
+		{'s', 2300 * time.Millisecond, "", false},
+	}
+	for i, step := range steps {
+		c, fired, used := matcher.Press(step.key, start.Add(step.after))
+		if fired != (step.fired != "") || c.Option.Title != step.fired || used != step.used {
+			t.Errorf("step %d, %q: fired %q (%t), used %t, want %q, used %t", i, step.key, c.Option.Title, fired, used, step.fired, step.used)
+		}
+	}
+}
+
+func TestCollectChords(t *testing.T) {
+	options := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Status", Command: "git status", Chord: "g s"},
+			{Title: "Stash", Command: "git stash", Chord: "g s p"},
+			{Title: "Quit", Command: "exit", Chord: "q"},
+			{Title: "Long", Command: "true", Chord: "gg"},
+			{Title: "Off", Command: "false", Chord: "o", Disabled: true},
+		}},
+	}
+
+	chords, errs := collectChords(options, map[string][]rune{"quit": {'q'}})
+	if len(chords) != 1 || chords[0].Option.Title != "Status" {
+		t.Errorf("collectChords() kept %v, want only the chord of Status", chords)
+	}
+	want := []string{
+		`Git/Stash: chord "g s p" clashes with "g s" of Git/Status`,
+		`Git/Quit: chord "q" uses 'q', the quit key`,
+		`Git/Long: chord "gg": keys have to be single characters, got "gg"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("collectChords() errors = %v, want %d", errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
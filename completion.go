@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// optionPaths lists the "/" separated path of every option --select can pick
+func optionPaths(options []Option) []string {
+	var paths []string
+	for _, opt := range flattenOptions(options) {
+		paths = append(paths, joinPath(opt.Path, opt.Title))
+	}
+	return paths
+}
+
+// findOptionByPath finds the option --select names by its path
+func findOptionByPath(options []Option, path string) (Option, bool) {
+	for _, opt := range flattenOptions(options) {
+		if joinPath(opt.Path, opt.Title) == path {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
+
+// checkSelected reports why the option --select or --first picked, named by
+// its path, can't be printed or run without the menu. Options that ask for
+// confirmation need yes, a confirmPhrase can only be typed in the menu.
+func checkSelected(option Option, name string, command string, confirmPattern *regexp.Regexp, yes bool) error {
+	switch {
+	case option.Disabled:
+		return fmt.Errorf("%q is disabled", name)
+	case isMissingCommand(option):
+		return fmt.Errorf("%q has no command", name)
+	case len(parseParameters(option.Command)) > 0:
+		return fmt.Errorf("%q takes parameters, pick it from the menu instead", name)
+	case option.ConfirmPhrase != "":
+		return fmt.Errorf("%q has to be confirmed by typing a phrase, pick it from the menu instead", name)
+	case needsConfirmation(option, command, confirmPattern) && !yes:
+		return fmt.Errorf("%q asks for confirmation, pick it from the menu or pass --yes", name)
+	}
+	return nil
+}
+
+// completionScript returns a completion script for shell offering flags and,
+// after --select, the paths printed by talias --list-paths so they stay current
+func completionScript(shell string, flags []string) (string, error) {
+	names := make([]string, len(flags))
+	for i, name := range flags {
+		names[i] = "--" + name
+	}
+	flagList := strings.Join(names, " ")
+
+	switch shell {
+	case "bash":
+		return `_talias() {
+  local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+  if [ "$prev" = "--select" ]; then
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(talias --list-paths 2>/dev/null)" -- "$cur"))
+    COMPREPLY=("${COMPREPLY[@]// /\\ }")
+    return
+  fi
+  COMPREPLY=($(compgen -W "` + flagList + `" -- "$cur"))
+}
+complete -F _talias talias
+`, nil
+	case "zsh":
+		return `#compdef talias
+_talias() {
+  if [[ ${words[CURRENT-1]} == --select ]]; then
+    local -a paths
+    paths=("${(@f)$(talias --list-paths 2>/dev/null)}")
+    compadd -a paths
+    return
+  fi
+  compadd -- ` + flagList + `
+}
+compdef _talias talias
+`, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q for completion, use bash or zsh", shell)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckSelected(t *testing.T) {
+	pattern := regexp.MustCompile(`rm -rf`)
+	tests := []struct {
+		name    string
+		option  Option
+		command string
+		yes     bool
+		wantErr string
+	}{
+		{"plain", Option{Title: "List", Command: "ls"}, "ls", false, ""},
+		{"disabled", Option{Title: "List", Command: "ls", Disabled: true}, "ls", false, "disabled"},
+		{"no command", Option{Title: "List", Command: "  "}, "  ", false, "no command"},
+		{"parameters", Option{Title: "Greet", Command: "echo ${1:name}"}, "echo ${1:name}", false, "takes parameters"},
+		{"confirm", Option{Title: "Delete", Command: "rm x", Confirm: true}, "rm x", false, "asks for confirmation"},
+		{"confirm with yes", Option{Title: "Delete", Command: "rm x", Confirm: true}, "rm x", true, ""},
+		{"pattern", Option{Title: "Wipe", Command: "rm -rf /tmp/x"}, "rm -rf /tmp/x", false, "asks for confirmation"},
+		{"pattern after expansion", Option{Title: "Wipe", Command: "$WIPE"}, "rm -rf /tmp/x", false, "asks for confirmation"},
+		{"pattern with yes", Option{Title: "Wipe", Command: "rm -rf /tmp/x"}, "rm -rf /tmp/x", true, ""},
+		{"phrase", Option{Title: "Drop", Command: "drop db", ConfirmPhrase: "prod"}, "drop db", true, "typing a phrase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSelected(tt.option, tt.option.Title, tt.command, pattern, tt.yes)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkSelected() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkSelected() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptionPaths(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Up", Command: "docker compose up"},
+			{Title: "Logs", Children: []Option{{Title: "Follow", Command: "docker compose logs -f"}}},
+		}},
+		{Title: "Build", Command: "make", Run: true, Children: []Option{{Title: "Clean", Command: "make clean"}}},
+		{Title: "Empty", Children: []Option{}},
+		{Title: "List", Command: "ls"},
+	}
+	want := []string{"Docker/Up", "Docker/Logs/Follow", "Build", "Build/Clean", "List"}
+	if got := optionPaths(options); !slices.Equal(got, want) {
+		t.Fatalf("optionPaths() = %q, want %q", got, want)
+	}
+	for _, path := range want {
+		if _, ok := findOptionByPath(options, path); !ok {
+			t.Errorf("findOptionByPath(%q) found nothing", path)
+		}
+	}
+	if _, ok := findOptionByPath(options, "Docker"); ok {
+		t.Error("findOptionByPath(\"Docker\") found a category")
+	}
+}
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh"} {
+		t.Run(shell, func(t *testing.T) {
+			script, err := completionScript(shell, []string{"select", "yes"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{"talias --list-paths", "--select --yes", "_talias"} {
+				if !strings.Contains(script, want) {
+					t.Errorf("%s script doesn't contain %q:\n%s", shell, want, script)
+				}
+			}
+		})
+	}
+	if _, err := completionScript("fish", nil); err == nil {
+		t.Error("completionScript(\"fish\") = nil error, want unsupported shell")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Config is a parsed config file, either a bare array of options or an object
+// carrying settings alongside them:
+//
+//	{"options": [...], "templates": {...}, "theme": {...}, "keys": {...}, "vars": {...}}
+type Config struct {
+	Options   []Option           `json:"options"`
+	Templates map[string]Option  `json:"templates,omitempty"` // partial options by name, see Option.Use
+	Theme     themeConfig        `json:"theme,omitempty"`
+	Keys      map[string]keyList `json:"keys,omitempty"` // action name to its keys, see defaultKeys
+	Vars      map[string]string  `json:"vars,omitempty"` // variables for commands, like the env file
+
+	// Merge is the order plugins are merged in, "filename" (the default) or
+	// "mtime", plugins named in Order come first regardless
+	Merge string   `json:"merge,omitempty"`
+	Order []string `json:"order,omitempty"`
+
+	// Welcome replaces the text shown when talias starts, EmptyMessage the one
+	// for empty categories and menus
+	Welcome      string `json:"welcome,omitempty"`
+	EmptyMessage string `json:"emptyMessage,omitempty"`
+
+	// ConfirmPattern is a regular expression for commands that are confirmed
+	// before they run, as if their option was marked confirm
+	ConfirmPattern string `json:"confirmPattern,omitempty"`
+
+	// ExecTimeout is how long commands run with --exec may take, e.g. "2m",
+	// unless their option sets timeoutSeconds
+	ExecTimeout string `json:"execTimeout,omitempty"`
+
+	// CategoryOrder lists each menu's categories "first" or "last", apart from
+	// its leaves, instead of in the order they're configured
+	CategoryOrder string `json:"categoryOrder,omitempty"`
+
+	// CategoryEnter is "preview" to list a category's children in the info box
+	// on Enter, opening it with Right, instead of opening it right away
+	CategoryEnter string `json:"categoryEnter,omitempty"`
+
+	// OnSelect is a command started in the background whenever an option runs,
+	// with TALIAS_TITLE and TALIAS_PATH describing the option
+	OnSelect string `json:"onSelect,omitempty"`
+
+	// RespectBackground clears the screen before every frame so the terminal's
+	// background shows through the panes, unset means true. Terminals that
+	// flicker from the clear can turn it off to get solid panes instead.
+	RespectBackground *bool `json:"respectBackground,omitempty"`
+}
+
+// themeConfig names the colors of the UI, empty fields keep the color from
+// TALIAS_* variables or the default
+type themeConfig struct {
+	Highlight string `json:"highlight,omitempty"`
+	Selected  string `json:"selected,omitempty"`
+	Border    string `json:"border,omitempty"`
+	Selection string `json:"selection,omitempty"` // "highlight", "arrow" or "both"
+
+	Levels map[string]string `json:"levels,omitempty"` // colors by option level
+}
+
+// parseConfig decodes either form of config, telling them apart by the first
+// character so a bare array keeps working
+func parseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := checkText(data); err != nil {
+		return config, err
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &config); err != nil {
+			return config, fmt.Errorf("failed to parse JSON: %v", err)
+		}
+		if config.Options == nil {
+			return config, fmt.Errorf("config object has no \"options\"")
+		}
+		return config, nil
+	}
+
+	if err := json.Unmarshal(trimmed, &config.Options); err != nil {
+		return config, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return config, nil
+}
+
+// checkText rejects data that isn't UTF-8 text before it's parsed, a binary file
+// would otherwise fail with a cryptic JSON error about an invalid character
+func checkText(data []byte) error {
+	if utf8.Valid(data) && !bytes.ContainsRune(data, 0) {
+		return nil
+	}
+	return fmt.Errorf("config doesn't look like a JSON text file, it looks like %s", http.DetectContentType(data))
+}
+
+// apply overrides colors of base with the ones the config names
+func (t themeConfig) apply(base theme) (theme, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+		color *tcell.Color
+	}{
+		{"highlight", t.Highlight, &base.Highlight},
+		{"selected", t.Selected, &base.Selected},
+		{"border", t.Border, &base.Border},
+	} {
+		if field.value == "" {
+			continue
+		}
+		color, err := parseColor(field.value)
+		if err != nil {
+			return base, fmt.Errorf("theme %s: %v", field.name, err)
+		}
+		*field.color = color
+	}
+
+	if len(t.Levels) > 0 {
+		// The default theme's map is shared
+		base.Levels = maps.Clone(base.Levels)
+	}
+	for level, name := range t.Levels {
+		if !isLevel(level) {
+			return base, fmt.Errorf("theme levels: unknown level %q, use %q, %q or %q", level, levelInfo, levelWarn, levelDanger)
+		}
+		color, err := parseColor(name)
+		if err != nil {
+			return base, fmt.Errorf("theme levels %s: %v", level, err)
+		}
+		base.Levels[level] = color
+	}
+
+	switch t.Selection {
+	case "":
+	case selectionHighlight, selectionArrow, selectionBoth:
+		base.Selection = t.Selection
+	default:
+		return base, fmt.Errorf("theme selection has to be %q, %q or %q, got %q", selectionHighlight, selectionArrow, selectionBoth, t.Selection)
+	}
+	return base, nil
+}
+
+// envVars lists the config's vars in a stable order for applyEnv
+func (c Config) envVars() []envVar {
+	keys := make([]string, 0, len(c.Vars))
+	for key := range c.Vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vars := make([]envVar, len(keys))
+	for i, key := range keys {
+		vars[i] = envVar{Key: key, Value: c.Vars[key]}
+	}
+	return vars
+}
+
+// respectsBackground reports whether the terminal's background shows through
+// the panes, which is the default
+func (c Config) respectsBackground() bool {
+	return c.RespectBackground == nil || *c.RespectBackground
+}
+
+// execTimeout parses ExecTimeout, zero when it isn't set
+func (c Config) execTimeout() (time.Duration, error) {
+	if c.ExecTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(c.ExecTimeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid execTimeout %q, use a duration like \"2m\"", c.ExecTimeout)
+	}
+	return timeout, nil
+}
+
+// messages are the UI strings the config replaces, they win over the language catalog
+func (c Config) messages() messages {
+	overrides := messages{}
+	if c.Welcome != "" {
+		overrides["welcome"] = c.Welcome
+	}
+	if c.EmptyMessage != "" {
+		overrides["emptyCategory"] = c.EmptyMessage
+	}
+	return overrides
+}
+
+// Keys of the single key actions in normal mode, configurable under "keys"
+var defaultKeys = map[string][]rune{
+	"quit":     {'q'},
+	"search":   {'?'},
+	"root":     {'~'},
+	"rerun":    {'.'},
+	"copy":     {'Y'},
+	"edit":     {'e'},
+	"filter":   {'f'},
+	"open":     {'>'},
+	"copyPath": {'P'},
+	"debug":    {'D'},
+	"goto":     {':'},
+	"compact":  {'C'},
+	"mark":     {' '},
+	"back":     nil, // Escape always goes back, these are extra keys for it
+}
+
+// keyList is the keys of an action, written as one key or a list of them:
+// "quit": "x" or "quit": ["q", "x"]
+type keyList []string
+
+func (k *keyList) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = keyList{key}
+		return nil
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("keys have to be a string or a list of strings, got %s", data)
+	}
+	*k = keys
+	return nil
+}
+
+// resolveKeys applies the config's keys over defaultKeys, replacing an
+// action's default keys. Each key has to be a single character.
+func resolveKeys(overrides map[string]keyList) (map[string][]rune, error) {
+	keys := make(map[string][]rune, len(defaultKeys))
+	for action, actionKeys := range defaultKeys {
+		keys[action] = actionKeys
+	}
+	for action, actionKeys := range overrides {
+		if _, known := defaultKeys[action]; !known {
+			return nil, fmt.Errorf("unknown key action %q", action)
+		}
+		runes := make([]rune, len(actionKeys))
+		for i, key := range actionKeys {
+			if utf8.RuneCountInString(key) != 1 {
+				return nil, fmt.Errorf("key for %s has to be a single character, got %q", action, key)
+			}
+			runes[i], _ = utf8.DecodeRuneInString(key)
+		}
+		keys[action] = runes
+	}
+	return keys, nil
+}
+
+// isActionKey reports whether key is one of the keys of action
+func isActionKey(keys map[string][]rune, action string, key rune) bool {
+	return slices.Contains(keys[action], key)
+}
+
+// pressedAction reports whether event is a key press of one of the keys of action
+func pressedAction(keys map[string][]rune, event *tcell.EventKey, action string) bool {
+	return event.Key() == tcell.KeyRune && isActionKey(keys, action, event.Rune())
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseConfigForms(t *testing.T) {
+	const options = `[
+		{"title": "Docker", "children": [{"title": "Ps", "command": "docker ps"}]},
+		{"title": "Top", "command": "top"}
+	]`
+	array, err := parseConfig([]byte(options))
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := parseConfig([]byte(`{
+		"options": ` + options + `,
+		"theme": {"highlight": "teal"},
+		"keys": {"quit": "x", "search": ["/", "?"]},
+		"vars": {"REGISTRY": "ghcr.io/acme"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(array.Options, object.Options) || len(array.Options) != 2 {
+		t.Errorf("options = %+v from the object, want the same as the array's %+v", object.Options, array.Options)
+	}
+	if object.Theme.Highlight != "teal" {
+		t.Errorf("theme highlight = %q, want teal", object.Theme.Highlight)
+	}
+	if got := object.Keys["search"]; !reflect.DeepEqual(got, keyList{"/", "?"}) {
+		t.Errorf("search keys = %q, want / and ?", got)
+	}
+	if got := object.Keys["quit"]; !reflect.DeepEqual(got, keyList{"x"}) {
+		t.Errorf("quit keys = %q, want x", got)
+	}
+	if got := object.Vars["REGISTRY"]; got != "ghcr.io/acme" {
+		t.Errorf("REGISTRY = %q, want ghcr.io/acme", got)
+	}
+	if !reflect.DeepEqual(array.Theme, themeConfig{}) || array.Keys != nil || array.Vars != nil {
+		t.Errorf("array config has settings: %+v", array)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"object without options", `{"theme": {"highlight": "teal"}}`, `no "options"`},
+		{"broken array", `[{"title": "Top",}]`, "failed to parse JSON"},
+		{"broken object", `{"options": [}`, "failed to parse JSON"},
+		{"bad keys", `{"options": [], "keys": {"quit": 1}}`, "keys have to be a string or a list of strings"},
+	}
+	for _, tt := range tests {
+		if _, err := parseConfig([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: parseConfig() = %v, want an error mentioning %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Leading whitespace and a byte order mark don't hide the object form
+	config, err := parseConfig([]byte("\ufeff\n  {\"options\": [{\"title\": \"Top\", \"command\": \"top\"}]}"))
+	if err != nil || len(config.Options) != 1 {
+		t.Errorf("parseConfig() with a BOM = %+v, %v", config, err)
+	}
+}
+
+func TestConfigMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		wantWelcome string
+		wantEmpty   string
+	}{
+		{"defaults", Config{}, "Welcome! Select an option.", defaultMessages["emptyCategory"]},
+		{"configured", Config{Welcome: "Ops menu, pick with care", EmptyMessage: "Nothing to run here"}, "Ops menu, pick with care", "Nothing to run here"},
+		{"welcome only", Config{Welcome: "Hi"}, "Hi", defaultMessages["emptyCategory"]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Like main, the config wins over the language catalog
+			msg := messages{"emptyCategory": defaultMessages["emptyCategory"]}
+			for key, text := range tt.config.messages() {
+				msg[key] = text
+			}
+			if got := msg.get("welcome"); got != tt.wantWelcome {
+				t.Errorf("welcome = %q, want %q", got, tt.wantWelcome)
+			}
+			if got := msg.get("emptyCategory"); got != tt.wantEmpty {
+				t.Errorf("emptyCategory = %q, want %q", got, tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestRespectBackground(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{`{"options": []}`, true},
+		{`{"options": [], "respectBackground": true}`, true},
+		{`{"options": [], "respectBackground": false}`, false},
+	}
+	for _, tt := range tests {
+		config, err := parseConfig([]byte(tt.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := config.respectsBackground(); got != tt.want {
+			t.Errorf("respectsBackground() for %s = %t, want %t", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestSeveralKeysTriggerTheSameAction(t *testing.T) {
+	config, err := parseConfig([]byte(`{"options": [], "keys": {"back": ["h", "b"], "quit": "x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := resolveKeys(config.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		event  *tcell.EventKey
+		action string
+		want   bool
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone), "back", true},
+		{tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone), "back", true},
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), "quit", true},
+		// Configured keys replace the default
+		{tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), "quit", false},
+		// Untouched actions keep theirs
+		{tcell.NewEventKey(tcell.KeyRune, '?', tcell.ModNone), "search", true},
+		{tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone), "quit", false},
+		{tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), "back", false},
+	}
+	for _, tt := range tests {
+		if got := pressedAction(keys, tt.event, tt.action); got != tt.want {
+			t.Errorf("pressedAction(%s, %s) = %t, want %t", tt.event.Name(), tt.action, got, tt.want)
+		}
+	}
+
+	if _, err := resolveKeys(map[string]keyList{"quit": {"x", "ctrl"}}); err == nil {
+		t.Errorf("resolveKeys() accepted a key longer than a character")
+	}
+}
+
+func TestBinaryConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"gzip", []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03}, "it looks like application/x-gzip"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "it looks like image/png"},
+		{"latin-1", []byte(`[{"title": "Caf` + "\xe9" + `"}]`), "doesn't look like a JSON text file"},
+		{"nul bytes", []byte("[\x00]"), "doesn't look like a JSON text file"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "options.json")
+		if err := os.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfigFromFile(path, "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: loadConfigFromFile() = %v, want an error naming the file and containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"2m", 2 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"0s", 0, false},
+		{"-5s", 0, true},
+		{"2 minutes", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Config{ExecTimeout: tt.value}.execTimeout()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("execTimeout(%q) = %v, %v, want %v, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
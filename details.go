@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+)
+
+// How long a $(...) command in details may run
+const defaultSubstitutionTimeout = 2 * time.Second
+
+// Default caps on the details shown while moving through the list, the rest
+// is only rendered when the info box is focused
+const (
+	defaultDetailsMaxBytes = 4096
+	defaultDetailsMaxLines = 50
+)
+
+// truncateDetails cuts details to at most maxLines lines and maxBytes bytes
+// without splitting a character, a cap of 0 or less means no cap
+func truncateDetails(details string, maxBytes int, maxLines int) (string, bool) {
+	truncated := false
+	if maxLines > 0 {
+		lines := strings.SplitN(details, "\n", maxLines+1)
+		if len(lines) > maxLines {
+			details = strings.Join(lines[:maxLines], "\n")
+			truncated = true
+		}
+	}
+	if maxBytes > 0 && len(details) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(details[cut]) {
+			cut--
+		}
+		details = details[:cut]
+		truncated = true
+	}
+	return details, truncated
+}
+
+// templateDetails renders details as a text/template with option as its data,
+// e.g. "Runs {{.Command}}". Details that don't parse or fail to render are
+// returned as written.
+func templateDetails(details string, option Option) string {
+	if !strings.Contains(details, "{{") {
+		return details
+	}
+	tmpl, err := template.New(option.Title).Option("missingkey=error").Parse(details)
+	if err != nil {
+		return details
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, option); err != nil {
+		return details
+	}
+	return rendered.String()
+}
+
+// Matches $(command) in details, the command can't contain parentheses
+var substitutionPattern = regexp.MustCompile(`\$\(([^()]*)\)`)
+
+// outputRunner runs a command and returns its output
+type outputRunner func(ctx context.Context, command string) (string, error)
+
+// shellOutput runs command through sh -c and returns its stdout
+func shellOutput(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// Kill anything the command started too, a child still holding stdout
+	// would keep Output waiting past the timeout
+	useProcessGroup(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %q: %v", command, err)
+	}
+	return string(out), nil
+}
+
+// detailsSubstituter splices the output of $(...) commands into details, each
+// command runs at most once per session
+type detailsSubstituter struct {
+	run     outputRunner
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func newDetailsSubstituter(run outputRunner, timeout time.Duration) *detailsSubstituter {
+	return &detailsSubstituter{run: run, timeout: timeout, cache: make(map[string]string)}
+}
+
+// Timeout is how long Expand may take for details, each command gets its own
+// timeout
+func (d *detailsSubstituter) Timeout(details string) time.Duration {
+	return time.Duration(len(substitutionPattern.FindAllString(details, -1))+1) * d.timeout
+}
+
+// Expand replaces each $(command) with its trimmed output, or an [error]
+// marker if it fails, escaped for display in a tview text view. Commands still
+// running when ctx is done are killed and their output isn't kept.
+func (d *detailsSubstituter) Expand(ctx context.Context, details string) string {
+	if !strings.Contains(details, "$(") {
+		return details
+	}
+
+	return substitutionPattern.ReplaceAllStringFunc(details, func(match string) string {
+		command := substitutionPattern.FindStringSubmatch(match)[1]
+		d.mu.Lock()
+		output, cached := d.cache[command]
+		d.mu.Unlock()
+		if cached || ctx.Err() != nil {
+			return output
+		}
+
+		runCtx, cancel := context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+		output, err := d.run(runCtx, command)
+		if err != nil {
+			output = "[error]"
+		}
+		output = tview.Escape(strings.TrimSpace(output))
+		if ctx.Err() == nil {
+			d.mu.Lock()
+			d.cache[command] = output
+			d.mu.Unlock()
+		}
+		return output
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Substitutions run on the details as written, the template is rendered
+// afterwards, like showDetails and renderDetails do
+func TestSubstitutionsDoNotRunTemplatedFields(t *testing.T) {
+	var ran []string
+	run := func(ctx context.Context, command string) (string, error) {
+		ran = append(ran, command)
+		return "output of " + command, nil
+	}
+	substituter := newDetailsSubstituter(run, time.Second)
+	option := Option{
+		Title:   "Clean up",
+		Command: "docker rm $(docker ps -aq)",
+		Details: "Runs {{.Command}} on $(hostname)",
+	}
+
+	got := templateDetails(substituter.Expand(context.Background(), option.Details), option)
+	want := "Runs docker rm $(docker ps -aq) on output of hostname"
+	if got != want {
+		t.Errorf("details = %q, want %q", got, want)
+	}
+	if len(ran) != 1 || ran[0] != "hostname" {
+		t.Errorf("ran %q, want only the details' own hostname", ran)
+	}
+}
+
+func TestTemplateDetails(t *testing.T) {
+	option := Option{Title: "Deploy", Command: "make deploy"}
+	tests := []struct {
+		details string
+		want    string
+	}{
+		{"Runs {{.Command}}", "Runs make deploy"},
+		{"No template here", "No template here"},
+		{"Broken {{.Command", "Broken {{.Command"},
+		{"Unknown {{.Nope}}", "Unknown {{.Nope}}"},
+	}
+	for _, tt := range tests {
+		if got := templateDetails(tt.details, option); got != tt.want {
+			t.Errorf("templateDetails(%q) = %q, want %q", tt.details, got, tt.want)
+		}
+	}
+}
+
+func TestExpandDoesNotCacheCancelledRuns(t *testing.T) {
+	runs := 0
+	run := func(ctx context.Context, command string) (string, error) {
+		runs++
+		return "up", nil
+	}
+	substituter := newDetailsSubstituter(run, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	substituter.Expand(ctx, "Status: $(status)")
+	if runs != 0 {
+		t.Errorf("ran %d commands after the context was done, want none", runs)
+	}
+
+	for range 2 {
+		if got := substituter.Expand(context.Background(), "Status: $(status)"); got != "Status: up" {
+			t.Errorf("Expand() = %q, want %q", got, "Status: up")
+		}
+	}
+	if runs != 1 {
+		t.Errorf("ran the command %d times, want it cached after the first", runs)
+	}
+}
+
+func TestTruncateDetails(t *testing.T) {
+	tests := []struct {
+		name          string
+		details       string
+		maxBytes      int
+		maxLines      int
+		want          string
+		wantTruncated bool
+	}{
+		{"under both caps", "a\nb", 10, 5, "a\nb", false},
+		{"no caps", "a\nb\nc", 0, 0, "a\nb\nc", false},
+		{"line cap", "a\nb\nc\nd", 0, 2, "a\nb", true},
+		{"exactly the line cap", "a\nb", 0, 2, "a\nb", false},
+		{"byte cap", "abcdef", 4, 0, "abcd", true},
+		{"exactly the byte cap", "abcd", 4, 0, "abcd", false},
+		{"lines then bytes", "abc\ndef\nghi", 5, 2, "abc\nd", true},
+		{"doesn't split a character", "aé", 2, 0, "a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateDetails(tt.details, tt.maxBytes, tt.maxLines)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("truncateDetails(%q, %d, %d) = %q, %v, want %q, %v", tt.details, tt.maxBytes, tt.maxLines, got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
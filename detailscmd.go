@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// How long a detailsCmd may run unless its option sets detailsTimeoutSeconds
+const defaultDetailsCmdTimeout = 5 * time.Second
+
+// errDetailsTimedOut is reported for a detailsCmd that ran into its timeout
+var errDetailsTimedOut = errors.New("details timed out")
+
+// detailsCmdRunner runs the detailsCmd of the selected option in the
+// background. Only the latest run matters, starting another one or calling
+// Cancel drops the one in flight. Output of successful runs is kept for the
+// session.
+type detailsCmdRunner struct {
+	run    outputRunner
+	cache  map[string]string
+	cancel context.CancelFunc
+}
+
+func newDetailsCmdRunner(run outputRunner) *detailsCmdRunner {
+	return &detailsCmdRunner{run: run, cache: make(map[string]string)}
+}
+
+// Cached returns the output of an earlier successful run for key
+func (r *detailsCmdRunner) Cached(key string) (string, bool) {
+	output, cached := r.cache[key]
+	return output, cached
+}
+
+// Start runs command for key, killing it after timeout. done gets its output
+// or error through update, which has to run it on the UI goroutine, unless the
+// run was cancelled by then. The returned context is done once it's cancelled.
+func (r *detailsCmdRunner) Start(ctx context.Context, key string, command string, timeout time.Duration, update func(func()), done func(output string, err error)) context.Context {
+	r.Cancel()
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+
+	go func() {
+		runCtx, stop := context.WithTimeout(ctx, timeout)
+		defer stop()
+		output, err := r.run(runCtx, command)
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = errDetailsTimedOut
+		}
+		update(func() {
+			if ctx.Err() != nil {
+				// Another option was selected while the command ran
+				return
+			}
+			cancel()
+			if err == nil {
+				r.cache[key] = output
+			}
+			done(output, err)
+		})
+	}()
+	return ctx
+}
+
+// Cancel drops the run in flight, if any
+func (r *detailsCmdRunner) Cancel() {
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDetailsCmdCancelledOnReselect(t *testing.T) {
+	// The first command only returns once the test lets it, after the second
+	// option was selected
+	release := make(chan struct{})
+	run := func(ctx context.Context, command string) (string, error) {
+		if command == "slow" {
+			<-release
+			return "stale", nil
+		}
+		return "fresh", nil
+	}
+	runner := newDetailsCmdRunner(run)
+	updates := make(chan func(), 2)
+	update := func(f func()) { updates <- f }
+
+	var shown []string
+	done := func(output string, err error) { shown = append(shown, output) }
+	first := runner.Start(context.Background(), "Slow", "slow", time.Second, update, done)
+	runner.Start(context.Background(), "Fast", "fast", time.Second, update, done)
+	if first.Err() == nil {
+		t.Fatal("selecting another option didn't cancel the first run")
+	}
+
+	(<-updates)()
+	close(release)
+	(<-updates)()
+	if len(shown) != 1 || shown[0] != "fresh" {
+		t.Errorf("shown %q, want only the second option's output", shown)
+	}
+	if _, cached := runner.Cached("Slow"); cached {
+		t.Errorf("the cancelled run's output was cached")
+	}
+	if output, cached := runner.Cached("Fast"); !cached || output != "fresh" {
+		t.Errorf("Cached(Fast) = %q, %t, want the output kept for the session", output, cached)
+	}
+}
+
+func TestDetailsCmdTimeout(t *testing.T) {
+	run := func(ctx context.Context, command string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	runner := newDetailsCmdRunner(run)
+	updates := make(chan func(), 1)
+
+	var gotErr error
+	runner.Start(context.Background(), "Slow", "sleep 10", 10*time.Millisecond, func(f func()) { updates <- f }, func(output string, err error) {
+		gotErr = err
+	})
+	select {
+	case f := <-updates:
+		f()
+	case <-time.After(time.Second):
+		t.Fatal("the timed out run never finished")
+	}
+	if !errors.Is(gotErr, errDetailsTimedOut) {
+		t.Errorf("err = %v, want %v", gotErr, errDetailsTimedOut)
+	}
+	if _, cached := runner.Cached("Slow"); cached {
+		t.Errorf("a timed out run was cached")
+	}
+}
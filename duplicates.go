@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// duplicateTitles lists the breadcrumb path of every option whose title
+// repeats one of its earlier siblings, searching the whole tree
+func duplicateTitles(options []Option, path string) []string {
+	var duplicates []string
+	seen := make(map[string]bool)
+	for _, opt := range options {
+		if opt.Separator {
+			// Several separators may well read the same
+			continue
+		}
+		if seen[opt.Title] {
+			duplicates = append(duplicates, joinPath(path, opt.Title))
+		}
+		seen[opt.Title] = true
+		duplicates = append(duplicates, duplicateTitles(opt.Children, joinPath(path, opt.Title))...)
+	}
+	return duplicates
+}
+
+// renameDuplicateTitles suffixes titles that repeat an earlier sibling's with
+// " (2)", " (3)" and so on, skipping suffixes that are already taken
+func renameDuplicateTitles(options []Option) []Option {
+	taken := make(map[string]bool)
+	for _, opt := range options {
+		taken[opt.Title] = true
+	}
+
+	result := make([]Option, len(options))
+	seen := make(map[string]bool)
+	for i, opt := range options {
+		if opt.Separator {
+			result[i] = opt
+			continue
+		}
+		if seen[opt.Title] {
+			title := opt.Title
+			for n := 2; ; n++ {
+				title = fmt.Sprintf("%s (%d)", opt.Title, n)
+				if !taken[title] {
+					break
+				}
+			}
+			taken[title] = true
+			opt.Title = title
+		}
+		seen[opt.Title] = true
+
+		if len(opt.Children) > 0 {
+			opt.Children = renameDuplicateTitles(opt.Children)
+		}
+		result[i] = opt
+	}
+	return result
+}
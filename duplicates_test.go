@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDuplicateTitles(t *testing.T) {
+	options := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Log", Command: "git log"},
+			{Title: "Log", Command: "git log --oneline"},
+		}},
+		{Title: "Git", Command: "git"},
+		{Title: "Top", Command: "top"},
+		{Separator: true},
+		{Separator: true},
+	}
+
+	got := duplicateTitles(options, "")
+	if want := []string{"Git/Log", "Git"}; !slices.Equal(got, want) {
+		t.Errorf("duplicateTitles() = %q, want %q", got, want)
+	}
+}
+
+func TestRenameDuplicateTitles(t *testing.T) {
+	options := []Option{
+		{Title: "Deploy", Command: "make deploy"},
+		{Title: "Deploy (2)", Command: "make deploy-2"}, // already taken
+		{Title: "Deploy", Command: "make deploy-api"},
+		{Title: "Deploy", Children: []Option{
+			{Title: "Logs", Command: "logs a"},
+			{Title: "Logs", Command: "logs b"},
+		}},
+		{Separator: true},
+		{Separator: true},
+	}
+
+	renamed := renameDuplicateTitles(options)
+	want := []string{"Deploy", "Deploy (2)", "Deploy (3)", "Deploy (4)", "", ""}
+	if got := titles(renamed); !slices.Equal(got, want) {
+		t.Errorf("renameDuplicateTitles() = %q, want %q", got, want)
+	}
+	if got := titles(renamed[3].Children); !slices.Equal(got, []string{"Logs", "Logs (2)"}) {
+		t.Errorf("children = %q, want [Logs Logs (2)]", got)
+	}
+	if dups := duplicateTitles(renamed, ""); len(dups) != 0 {
+		t.Errorf("still duplicated after renaming: %q", dups)
+	}
+	// The config as loaded isn't changed
+	if options[2].Title != "Deploy" {
+		t.Errorf("renameDuplicateTitles() changed its input to %q", options[2].Title)
+	}
+}
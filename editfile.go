@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"talias/internal/shellquote"
+)
+
+// Editor for editFile options when $EDITOR isn't set
+const defaultEditor = "vi"
+
+// editorCommand is the command opening path in editor, which can bring its own
+// arguments like "code -w"
+func editorCommand(editor string, path string) []string {
+	if strings.TrimSpace(editor) == "" {
+		editor = defaultEditor
+	}
+	return []string{"sh", "-c", editor + " " + shellquote.Quote(path)}
+}
+
+// opensEditor reports whether selecting option opens its editFile, which wins
+// over a command it might also have
+func opensEditor(option Option) bool {
+	return option.EditFile != "" && !option.Disabled
+}
+
+// editFilePath expands environment variables and ~/ in an editFile path
+func editFilePath(path string) string {
+	return expandCommand(os.ExpandEnv(path))
+}
+
+// runEditor runs an editor command on the terminal. The shell wrapper reads
+// talias' stdout, so the editor gets /dev/tty where there is one.
+func runEditor(args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		cmd.Stdin, cmd.Stdout = tty, tty
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %v", args[len(args)-1], err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor string
+		path   string
+		want   []string
+	}{
+		{"nvim", "/etc/hosts", []string{"sh", "-c", "nvim /etc/hosts"}},
+		{"code -w", "/tmp/my notes.md", []string{"sh", "-c", "code -w '/tmp/my notes.md'"}},
+		{"", "/etc/hosts", []string{"sh", "-c", "vi /etc/hosts"}},
+		{"  ", "it's.txt", []string{"sh", "-c", `vi 'it'\''s.txt'`}},
+	}
+	for _, tt := range tests {
+		if got := editorCommand(tt.editor, tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("editorCommand(%q, %q) = %q, want %q", tt.editor, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEditFilePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("NOTES_DIR", "/srv/notes")
+	if got := editFilePath("$NOTES_DIR/todo.md"); got != "/srv/notes/todo.md" {
+		t.Errorf("editFilePath() = %q, want the variable expanded", got)
+	}
+	if got := editFilePath("~/.bashrc"); got != filepath.Join(home, ".bashrc") {
+		t.Errorf("editFilePath() = %q, want ~/ expanded to the home directory", got)
+	}
+}
+
+func TestOpensEditor(t *testing.T) {
+	tests := []struct {
+		option Option
+		want   bool
+	}{
+		{Option{Title: "Hosts", EditFile: "/etc/hosts"}, true},
+		{Option{Title: "Hosts", EditFile: "/etc/hosts", Command: "cat /etc/hosts"}, true},
+		{Option{Title: "Hosts", EditFile: "/etc/hosts", Disabled: true}, false},
+		{Option{Title: "Top", Command: "top"}, false},
+	}
+	for _, tt := range tests {
+		if got := opensEditor(tt.option); got != tt.want {
+			t.Errorf("opensEditor(%+v) = %t, want %t", tt.option, got, tt.want)
+		}
+	}
+}
+
+func TestRunEditor(t *testing.T) {
+	dir := t.TempDir()
+	opened := filepath.Join(dir, "opened")
+	path := filepath.Join(dir, "my notes.md")
+
+	// The "editor" writes the path it was given, quoting has to survive the shell
+	if err := runEditor(editorCommand("printf %s >"+opened, path)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != path {
+		t.Errorf("editor opened %q, want %q", got, path)
+	}
+
+	if err := runEditor(editorCommand("false", path)); err == nil {
+		t.Error("runEditor() with a failing editor succeeded")
+	}
+}
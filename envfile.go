@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envVar is one KEY=VALUE line of the env file
+type envVar struct {
+	Key   string
+	Value string
+}
+
+// Matches $NAME and ${NAME} references in commands
+var envReferencePattern = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvFile parses KEY=VALUE lines, skipping blank lines and # comments. Values
+// may be single quoted (taken literally) or double quoted (with \", \\ and \n
+// escapes), an optional "export " prefix is ignored and ~/ is expanded.
+func parseEnvFile(data string) ([]envVar, error) {
+	var vars []envVar
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+
+		value, err := unquoteEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		vars = append(vars, envVar{Key: key, Value: expandCommand(value)})
+	}
+	return vars, nil
+}
+
+// unquoteEnvValue strips the quotes from a value, unquoted values lose any trailing # comment
+func unquoteEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, `"`):
+		if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+			return "", fmt.Errorf("unterminated double quote")
+		}
+		replacer := strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\n`, "\n")
+		return replacer.Replace(value[1 : len(value)-1]), nil
+	}
+	if index := strings.Index(value, " #"); index >= 0 {
+		value = strings.TrimSpace(value[:index])
+	}
+	return value, nil
+}
+
+// loadEnvFile reads variables from filename, a missing file means no variables
+func loadEnvFile(filename string) ([]envVar, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+
+	vars, err := parseEnvFile(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return vars, nil
+}
+
+// applyEnv sets vars in the process environment, variables that are already
+// set win unless override is true, returns the names that were set
+func applyEnv(vars []envVar, override bool) map[string]bool {
+	applied := make(map[string]bool)
+	for _, v := range vars {
+		if _, exists := os.LookupEnv(v.Key); exists && !override && !applied[v.Key] {
+			continue
+		}
+		os.Setenv(v.Key, v.Value)
+		applied[v.Key] = true
+	}
+	return applied
+}
+
+// expandEnvVars replaces $NAME and ${NAME} references to the given names with
+// their values, other references are left for the shell
+func expandEnvVars(command string, names map[string]bool) string {
+	if len(names) == 0 {
+		return command
+	}
+	return envReferencePattern.ReplaceAllStringFunc(command, func(match string) string {
+		groups := envReferencePattern.FindStringSubmatch(match)
+		name := groups[1] + groups[2]
+		if !names[name] {
+			return match
+		}
+		return os.Getenv(name)
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	data := `# Deploy settings
+export REGION=eu-west-1
+STAGE = prod # the default
+
+SINGLE='$HOME # kept'
+DOUBLE="say \"hi\"\nback\\slash"
+CERTS=~/certs
+EMPTY=
+`
+	vars, err := parseEnvFile(data)
+	if err != nil {
+		t.Fatalf("parseEnvFile() failed: %v", err)
+	}
+	want := []envVar{
+		{"REGION", "eu-west-1"},
+		{"STAGE", "prod"},
+		{"SINGLE", "$HOME # kept"},
+		{"DOUBLE", "say \"hi\"\nback\\slash"},
+		{"CERTS", "/home/me/certs"},
+		{"EMPTY", ""},
+	}
+	if fmt.Sprint(vars) != fmt.Sprint(want) {
+		t.Errorf("parseEnvFile() = %q, want %q", vars, want)
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"NO_EQUALS", "line 1: expected KEY=VALUE"},
+		{"# comment\n1KEY=value", "line 2: expected KEY=VALUE"},
+		{"KEY='open", "line 1: unterminated single quote"},
+		{`KEY="open`, "line 1: unterminated double quote"},
+	}
+	for _, tt := range tests {
+		_, err := parseEnvFile(tt.data)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parseEnvFile(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestExpandEnvVars(t *testing.T) {
+	t.Setenv("REGION", "eu-west-1")
+	t.Setenv("USER", "me")
+	got := expandEnvVars("deploy --region $REGION --to ${REGION}a --as $USER", map[string]bool{"REGION": true})
+	// Variables that didn't come from the env file are left for the shell
+	want := "deploy --region eu-west-1 --to eu-west-1a --as $USER"
+	if got != want {
+		t.Errorf("expandEnvVars() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+	"golang.org/x/term"
+
+	"talias/internal/shellquote"
+)
+
+// Exit status reported for commands killed by their timeout, same as timeout(1)
+const timeoutExitCode = 124
+
+// Exit status when the menu is closed without selecting anything, same as a
+// shell interrupted by Ctrl-C, so wrappers can tell an abort from a selection
+const abortExitCode = 130
+
+// commandRunner runs a shell command and reports its exit code, the command
+// is stopped when ctx is done
+type commandRunner interface {
+	Run(ctx context.Context, command string) (int, error)
+}
+
+// shellRunner runs commands through Shell -c attached to the terminal, when
+// Output is set the command's output is copied to it as well. Stdout replaces
+// the terminal as the command's stdout when set.
+type shellRunner struct {
+	Shell  string
+	Output io.Writer
+	Stdout io.Writer
+}
+
+func (r shellRunner) Run(ctx context.Context, command string) (int, error) {
+	var stdout io.Writer = os.Stdout
+	if r.Stdout != nil {
+		stdout = r.Stdout
+	}
+	cmd := exec.CommandContext(ctx, r.Shell, "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if r.Output != nil {
+		// The command no longer writes to a terminal, so it may behave differently
+		cmd.Stdout = io.MultiWriter(stdout, r.Output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, r.Output)
+	}
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		if isTerminal(os.Stdin) {
+			// A process group of its own would be in the terminal's background,
+			// stopped as soon as it reads the terminal and out of reach of
+			// Ctrl-C, so only the shell is killed. Don't wait long for anything
+			// it started that still holds the output open.
+			cmd.WaitDelay = time.Second
+		} else {
+			// Kill anything the command started too, not just the shell
+			useProcessGroup(cmd)
+		}
+	}
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to run command: %v", err)
+	}
+	return 0, nil
+}
+
+// isTerminal reports whether f is a terminal rather than a file, pipe or
+// another character device like /dev/null
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// resolveShell picks the shell to run a command with, the option's own shell
+// wins over the configured one, then $SHELL, then sh
+func resolveShell(optionShell string, configShell string, envShell string) string {
+	for _, shell := range []string{optionShell, configShell, envShell} {
+		if shell != "" {
+			return shell
+		}
+	}
+	return "sh"
+}
+
+// wrapInShell prefixes command to run in shell, for printing commands that need a specific shell
+func wrapInShell(shell string, command string) string {
+	return shellquote.Quote(shell) + " -c " + shellquote.Quote(command)
+}
+
+// printedCommand is the command to print for the wrapper. The parent shell
+// evaluates it, so it's only wrapped when the option or --shell asks for a
+// specific shell.
+func printedCommand(option Option, command string, configShell string) string {
+	if option.Shell == "" && configShell == "" {
+		return command
+	}
+	return wrapInShell(resolveShell(option.Shell, configShell, ""), command)
+}
+
+// needsConfirmation reports whether a command has to be confirmed before it
+// runs, because its option asks for it or it matches pattern as written or as
+// it will run
+func needsConfirmation(option Option, command string, pattern *regexp.Regexp) bool {
+	if option.Confirm || option.ConfirmPhrase != "" {
+		return true
+	}
+	return pattern != nil && (pattern.MatchString(option.Command) || pattern.MatchString(command))
+}
+
+// commandTimeout is how long an option's command may run, its own
+// timeoutSeconds wins over fallback, 0 means no timeout
+func commandTimeout(option Option, fallback time.Duration) time.Duration {
+	if option.TimeoutSeconds > 0 {
+		return time.Duration(option.TimeoutSeconds) * time.Second
+	}
+	return fallback
+}
+
+// runWithTimeout runs command with runner, killing it after timeout when positive
+func runWithTimeout(runner commandRunner, command string, timeout time.Duration) (int, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	code, err := runner.Run(ctx, command)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return timeoutExitCode, fmt.Errorf("command timed out after %v", timeout)
+	}
+	return code, err
+}
+
+// runSelected runs an option's command in exec mode and returns the exit status
+// to report, failures to start it are printed and count as status 1. The
+// command is killed after the option's timeout, or defaultTimeout when it has
+// none. Its output is copied to output unless it's nil. Options with
+// copyOutput have their stdout copied to the clipboard instead of printed.
+func runSelected(option Option, command string, configShell string, notifyAll bool, defaultTimeout time.Duration, output io.Writer) int {
+	timeout := commandTimeout(option, defaultTimeout)
+	shell := resolveShell(option.Shell, configShell, os.Getenv("SHELL"))
+	runner := shellRunner{Shell: shell, Output: output}
+	var captured bytes.Buffer
+	if option.CopyOutput {
+		runner.Stdout = &captured
+	}
+	code, err := runWithTimeout(runner, command, timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running command: %v\n", err)
+	}
+	if option.CopyOutput && err == nil {
+		code = copyOutput(copyToClipboard, option, captured.String(), code, diagnostics)
+	}
+	if notifyAll || option.Notify {
+		notifyCompletion(option.Title, code, err)
+	}
+	if code < 0 {
+		code = 1
+	}
+	return code
+}
+
+// copyOutput copies a command's stdout to the clipboard with copy once it
+// exited with code 0, without the final newline, and reports it to w. A failed
+// copy is reported as well and turns into status 1.
+func copyOutput(copy func(string) error, option Option, stdout string, code int, w io.Writer) int {
+	if code != 0 {
+		fmt.Fprintf(w, "Not copying the output of %s, it exited with status %d\n", option.Title, code)
+		return code
+	}
+	stdout = strings.TrimSuffix(strings.TrimSuffix(stdout, "\n"), "\r")
+	if err := copy(stdout); err != nil {
+		fmt.Fprintf(w, "Error copying output: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(w, "Copied the output of %s to the clipboard\n", option.Title)
+	return 0
+}
+
+// printPostMessage prints an option's post message to w once its command
+// succeeded and the UI is gone, main passes stderr to keep it out of anything
+// piping the output
+func printPostMessage(w io.Writer, option Option, code int) {
+	if code == 0 && option.PostMessage != "" {
+		fmt.Fprintln(w, option.PostMessage)
+	}
+}
+
+// tailBuffer keeps the last Max lines written to it
+type tailBuffer struct {
+	Max int
+
+	lines   []string
+	partial string
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	text := t.partial + string(p)
+	lines := strings.Split(text, "\n")
+	t.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		t.add(line)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) add(line string) {
+	t.lines = append(t.lines, strings.TrimRight(line, "\r"))
+	if len(t.lines) > t.Max {
+		t.lines = t.lines[len(t.lines)-t.Max:]
+	}
+}
+
+// Lines returns the kept lines, including a last one without a newline
+func (t *tailBuffer) Lines() []string {
+	if t.partial != "" {
+		t.add(t.partial)
+		t.partial = ""
+	}
+	return t.lines
+}
+
+// keepsMenuOpen reports whether the menu comes back after running option,
+// only commands run by talias itself can, printed ones end the session
+func keepsMenuOpen(option Option, execMode bool, loop bool) bool {
+	return execMode && (loop || option.KeepOpen)
+}
+
+// commandStatus is the info box text after a command ran in loop mode: whether
+// it succeeded, its post message when it did, and the tail of its output
+func commandStatus(msg messages, option Option, code int, tail []string) string {
+	var status string
+	if code == 0 {
+		status = "[green]" + msg.get("finished", tview.Escape(option.Title)) + "[-]"
+		if option.PostMessage != "" {
+			status += "\n" + option.PostMessage
+		}
+	} else if code == timeoutExitCode {
+		status = "[red]" + msg.get("runTimedOut", tview.Escape(option.Title), code) + "[-]"
+	} else {
+		status = "[red]" + msg.get("exitStatus", tview.Escape(option.Title), code) + "[-]"
+	}
+	if len(tail) > 0 {
+		status += "\n[gray]" + tview.Escape(strings.Join(tail, "\n")) + "[-]"
+	}
+	return status
+}
+
+// waitForEnter keeps a command's output on screen until Enter is pressed
+func waitForEnter(in io.Reader, out io.Writer) {
+	fmt.Fprint(out, "\nPress Enter to return to talias")
+	bufio.NewReader(in).ReadString('\n')
+}
+
+// completionMessage describes how a command finished for notifications
+func completionMessage(code int, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Failed: %v", err)
+	}
+	if code != 0 {
+		return fmt.Sprintf("Failed with exit status %d", code)
+	}
+	return "Finished successfully"
+}
+
+// notifierCommand returns the desktop notification command for goos, or nil
+// when no notifier is available
+func notifierCommand(goos string, lookPath func(string) (string, error), title string, message string) []string {
+	switch goos {
+	case "darwin":
+		quote := func(s string) string {
+			s = strings.ReplaceAll(s, `\`, `\\`)
+			return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+		}
+		return []string{"osascript", "-e", "display notification " + quote(message) + " with title " + quote(title)}
+	case "windows":
+		return nil
+	}
+
+	if _, err := lookPath("notify-send"); err != nil {
+		return nil
+	}
+	return []string{"notify-send", title, message}
+}
+
+// notifyCompletion sends a desktop notification for a finished command, failures are ignored
+func notifyCompletion(title string, code int, err error) {
+	args := notifierCommand(runtime.GOOS, exec.LookPath, title, completionMessage(code, err))
+	if args == nil {
+		return
+	}
+	exec.Command(args[0], args[1:]...).Run()
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifierCommand(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/notify-send", nil }
+	missing := func(string) (string, error) { return "", errors.New("not found") }
+
+	tests := []struct {
+		goos     string
+		lookPath func(string) (string, error)
+		want     []string
+	}{
+		{"linux", found, []string{"notify-send", `Deploy "prod"`, "Finished successfully"}},
+		{"freebsd", found, []string{"notify-send", `Deploy "prod"`, "Finished successfully"}},
+		{"linux", missing, nil},
+		// Quotes in the title can't break out of the AppleScript string
+		{"darwin", missing, []string{"osascript", "-e", `display notification "Finished successfully" with title "Deploy \"prod\""`}},
+		{"windows", found, nil},
+	}
+	for _, tt := range tests {
+		got := notifierCommand(tt.goos, tt.lookPath, `Deploy "prod"`, "Finished successfully")
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("notifierCommand(%s) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionMessage(t *testing.T) {
+	tests := []struct {
+		code int
+		err  error
+		want string
+	}{
+		{0, nil, "Finished successfully"},
+		{2, nil, "Failed with exit status 2"},
+		{-1, errors.New("no such shell"), "Failed: no such shell"},
+	}
+	for _, tt := range tests {
+		if got := completionMessage(tt.code, tt.err); got != tt.want {
+			t.Errorf("completionMessage(%d, %v) = %q, want %q", tt.code, tt.err, got, tt.want)
+		}
+	}
+}
+
+// runnerFunc adapts a function to commandRunner
+type runnerFunc func(ctx context.Context, command string) (int, error)
+
+func (f runnerFunc) Run(ctx context.Context, command string) (int, error) {
+	return f(ctx, command)
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	hang := runnerFunc(func(ctx context.Context, command string) (int, error) {
+		<-ctx.Done()
+		return -1, nil
+	})
+	code, err := runWithTimeout(hang, "sleep 100", 10*time.Millisecond)
+	if code != timeoutExitCode || err == nil {
+		t.Errorf("runWithTimeout() = %d, %v, want %d and an error", code, err, timeoutExitCode)
+	}
+
+	quick := runnerFunc(func(ctx context.Context, command string) (int, error) {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			t.Error("runWithTimeout() set a deadline without a timeout")
+		}
+		return 3, nil
+	})
+	if code, err := runWithTimeout(quick, "false", 0); code != 3 || err != nil {
+		t.Errorf("runWithTimeout() = %d, %v, want the command's own status 3", code, err)
+	}
+}
+
+func TestShellRunnerKillsTimedOutCommands(t *testing.T) {
+	start := time.Now()
+	// The shell's child holds stdout open, it has to be killed as well
+	code, _ := runWithTimeout(shellRunner{Shell: "sh"}, "sleep 5 | cat", 100*time.Millisecond)
+	if code != timeoutExitCode {
+		t.Errorf("code = %d, want %d", code, timeoutExitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %v to stop the command", elapsed)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	if got := commandTimeout(Option{TimeoutSeconds: 3}, time.Minute); got != 3*time.Second {
+		t.Errorf("commandTimeout() = %v, want the option's own 3s", got)
+	}
+	if got := commandTimeout(Option{}, time.Minute); got != time.Minute {
+		t.Errorf("commandTimeout() = %v, want the fallback", got)
+	}
+}
+
+func TestResolveShell(t *testing.T) {
+	tests := []struct {
+		option, config, env string
+		want                string
+	}{
+		{"zsh", "bash", "/bin/fish", "zsh"},
+		{"", "bash", "/bin/fish", "bash"},
+		{"", "", "/bin/fish", "/bin/fish"},
+		{"", "", "", "sh"},
+		{"zsh", "", "", "zsh"},
+	}
+	for _, tt := range tests {
+		if got := resolveShell(tt.option, tt.config, tt.env); got != tt.want {
+			t.Errorf("resolveShell(%q, %q, %q) = %q, want %q", tt.option, tt.config, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPrintedCommand(t *testing.T) {
+	tests := []struct {
+		shell, config string
+		want          string
+	}{
+		// The wrapper's own shell runs printed commands unless one is asked for
+		{"", "", "echo $((1 + 2))"},
+		{"bash", "", "bash -c 'echo $((1 + 2))'"},
+		{"", "zsh", "zsh -c 'echo $((1 + 2))'"},
+		{"bash", "zsh", "bash -c 'echo $((1 + 2))'"},
+	}
+	for _, tt := range tests {
+		option := Option{Title: "Sum", Command: "echo $((1 + 2))", Shell: tt.shell}
+		if got := printedCommand(option, option.Command, tt.config); got != tt.want {
+			t.Errorf("printedCommand(shell %q, config %q) = %q, want %q", tt.shell, tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestShellRunnerUsesItsShell(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	var out bytes.Buffer
+	if _, err := (shellRunner{Shell: "bash", Stdout: &out}).Run(context.Background(), `echo "${BASH_VERSION:+bash}"`); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "bash" {
+		t.Errorf("output = %q, want the command to run in bash", got)
+	}
+}
+
+func TestKeepsMenuOpen(t *testing.T) {
+	tests := []struct {
+		keepOpen bool
+		execMode bool
+		loop     bool
+		want     bool
+	}{
+		{false, true, true, true},
+		{true, true, false, true},
+		{false, true, false, false},
+		// Print mode always stops, the wrapper runs the command after talias exits
+		{true, false, false, false},
+		{false, false, true, false},
+	}
+	for _, tt := range tests {
+		option := Option{Title: "Build", Command: "make", KeepOpen: tt.keepOpen}
+		if got := keepsMenuOpen(option, tt.execMode, tt.loop); got != tt.want {
+			t.Errorf("keepsMenuOpen(keepOpen %t, exec %t, loop %t) = %t, want %t", tt.keepOpen, tt.execMode, tt.loop, got, tt.want)
+		}
+	}
+}
+
+func TestPrintPostMessage(t *testing.T) {
+	option := Option{Title: "Deploy", Command: "make deploy", PostMessage: "Deployed to prod, check the dashboard"}
+	tests := []struct {
+		option Option
+		code   int
+		want   string
+	}{
+		{option, 0, "Deployed to prod, check the dashboard\n"},
+		{option, 1, ""},
+		{Option{Title: "Build", Command: "make"}, 0, ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		printPostMessage(&out, tt.option, tt.code)
+		if out.String() != tt.want {
+			t.Errorf("printPostMessage(%q, %d) printed %q, want %q", tt.option.Title, tt.code, out.String(), tt.want)
+		}
+	}
+}
+
+func TestCommandStatus(t *testing.T) {
+	option := Option{Title: "Build", Command: "make", PostMessage: "See ./dist"}
+	tests := []struct {
+		code int
+		tail []string
+		want string
+	}{
+		{0, nil, "[green]Build finished[-]\nSee ./dist"},
+		{2, []string{"make: *** [all] Error 2"}, "[red]Build exited with status 2[-]\n[gray]make: *** [all[] Error 2[-]"},
+		{timeoutExitCode, nil, "[red]Build timed out and was stopped (status 124)[-]"},
+	}
+	for _, tt := range tests {
+		if got := commandStatus(messages{}, option, tt.code, tt.tail); got != tt.want {
+			t.Errorf("commandStatus(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tail := &tailBuffer{Max: 2}
+	for _, chunk := range []string{"one\ntw", "o\r\nthree\n", "four"} {
+		if _, err := tail.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := tail.Lines(), []string{"three", "four"}; !slices.Equal(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	pattern := regexp.MustCompile(`rm |sudo |kubectl delete`)
+	tests := []struct {
+		name    string
+		option  Option
+		command string
+		pattern *regexp.Regexp
+		want    bool
+	}{
+		{"matches", Option{Command: "sudo reboot"}, "sudo reboot", pattern, true},
+		{"matches mid command", Option{Command: "kubectl delete pod web"}, "kubectl delete pod web", pattern, true},
+		{"doesn't match", Option{Command: "kubectl get pods"}, "kubectl get pods", pattern, false},
+		{"matches once expanded", Option{Command: "$CLEAN"}, "rm -rf build", pattern, true},
+		{"no pattern", Option{Command: "sudo reboot"}, "sudo reboot", nil, false},
+		{"confirm without a match", Option{Command: "ls", Confirm: true}, "ls", pattern, true},
+		{"phrase without a match", Option{Command: "ls", ConfirmPhrase: "prod"}, "ls", pattern, true},
+	}
+	for _, tt := range tests {
+		if got := needsConfirmation(tt.option, tt.command, tt.pattern); got != tt.want {
+			t.Errorf("needsConfirmation(%s) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCopyOutput(t *testing.T) {
+	option := Option{Title: "Head", Command: "git rev-parse HEAD", CopyOutput: true}
+
+	// The command's stdout is captured instead of reaching the terminal
+	var captured bytes.Buffer
+	code, err := shellRunner{Shell: "sh", Stdout: &captured}.Run(context.Background(), `printf 'abc123\n'`)
+	if err != nil || code != 0 {
+		t.Fatalf("Run() = %d, %v", code, err)
+	}
+
+	failing := errors.New("no clipboard tool found")
+	tests := []struct {
+		name      string
+		code      int
+		copyErr   error
+		wantCode  int
+		wantCopy  string
+		wantPrint string
+	}{
+		{"copied", 0, nil, 0, "abc123", "Copied the output of Head to the clipboard\n"},
+		{"command failed", 2, nil, 2, "", "Not copying the output of Head, it exited with status 2\n"},
+		{"copy failed", 0, failing, 1, "abc123", "Error copying output: no clipboard tool found\n"},
+	}
+	for _, tt := range tests {
+		var copied string
+		clipboard := func(text string) error {
+			copied = text
+			return tt.copyErr
+		}
+		var out bytes.Buffer
+		if got := copyOutput(clipboard, option, captured.String(), tt.code, &out); got != tt.wantCode {
+			t.Errorf("%s: copyOutput() = %d, want %d", tt.name, got, tt.wantCode)
+		}
+		if copied != tt.wantCopy {
+			t.Errorf("%s: copied %q, want %q", tt.name, copied, tt.wantCopy)
+		}
+		if out.String() != tt.wantPrint {
+			t.Errorf("%s: printed %q, want %q", tt.name, out.String(), tt.wantPrint)
+		}
+	}
+}
+
+func TestExecTimeoutStopsHangingCommands(t *testing.T) {
+	config := Config{ExecTimeout: "20ms"}
+	execTimeout, err := config.execTimeout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hangs until its deadline, like a command waiting for input
+	hang := runnerFunc(func(ctx context.Context, command string) (int, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("%s ran without a deadline", command)
+			return 0, nil
+		}
+		<-ctx.Done()
+		return -1, nil
+	})
+
+	tests := []struct {
+		option  Option
+		timeout time.Duration
+	}{
+		{Option{Title: "Tail", Command: "tail -f log"}, 20 * time.Millisecond},
+		// The option's own timeout wins over the config's
+		{Option{Title: "Build", Command: "make", TimeoutSeconds: 1}, time.Second},
+	}
+	for _, tt := range tests {
+		timeout := commandTimeout(tt.option, execTimeout)
+		if timeout != tt.timeout {
+			t.Errorf("%s: timeout = %v, want %v", tt.option.Title, timeout, tt.timeout)
+		}
+		start := time.Now()
+		code, err := runWithTimeout(hang, tt.option.Command, timeout)
+		if elapsed := time.Since(start); elapsed < tt.timeout || elapsed > tt.timeout+time.Second {
+			t.Errorf("%s: stopped after %v, want about %v", tt.option.Title, elapsed, tt.timeout)
+		}
+		if code != timeoutExitCode || err == nil {
+			t.Errorf("%s: runWithTimeout() = %d, %v, want %d and an error", tt.option.Title, code, err, timeoutExitCode)
+		}
+		// Back in the menu the status line says it timed out
+		want := "[red]" + tt.option.Title + " timed out and was stopped (status 124)[-]"
+		if got := commandStatus(messages{}, tt.option, code, nil); got != want {
+			t.Errorf("status = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "os/exec"
+
+// useProcessGroup is a no-op on Windows, cancelling kills the shell process only
+func useProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exportMarkdown writes the menu tree as a Markdown document, each menu's
+// commands as a list followed by its categories as headings one level deeper
+func exportMarkdown(w io.Writer, options []Option) {
+	fmt.Fprintln(w, "# Main Menu")
+	writeMarkdownMenu(w, options, 1)
+}
+
+func writeMarkdownMenu(w io.Writer, options []Option, depth int) {
+	var categories []Option
+	wroteList := false
+	for _, opt := range options {
+		if opt.Separator {
+			continue
+		}
+		if len(opt.Children) > 0 || opt.ChildrenCmd != "" || isEmptyCategory(opt) {
+			categories = append(categories, opt)
+			continue
+		}
+		if !wroteList {
+			fmt.Fprintln(w)
+			wroteList = true
+		}
+		writeMarkdownLeaf(w, opt)
+	}
+
+	for _, category := range categories {
+		// Markdown has no headings below level 6
+		fmt.Fprintf(w, "\n%s %s\n", strings.Repeat("#", min(depth+1, 6)), category.Title)
+		if category.Details != "" {
+			fmt.Fprintf(w, "\n%s\n", category.Details)
+		}
+		if category.ChildrenCmd != "" {
+			fmt.Fprintf(w, "\nGenerated by %s\n", codeSpan(category.ChildrenCmd))
+		}
+		writeMarkdownMenu(w, category.Children, depth+1)
+	}
+}
+
+// writeMarkdownLeaf writes one list item with the command, and the details as a paragraph of it
+func writeMarkdownLeaf(w io.Writer, opt Option) {
+	line := "- **" + opt.Title + "**"
+	if opt.Command != "" {
+		line += ": " + codeSpan(opt.Command)
+	}
+	if opt.Disabled {
+		line += " (disabled"
+		if opt.DisabledReason != "" {
+			line += ": " + opt.DisabledReason
+		}
+		line += ")"
+	}
+	fmt.Fprintln(w, line)
+
+	if details := strings.TrimSpace(opt.Details); details != "" {
+		// Indented as a paragraph of the list item
+		fmt.Fprintln(w)
+		for _, detailLine := range strings.Split(details, "\n") {
+			fmt.Fprintln(w, strings.TrimRight("  "+detailLine, " "))
+		}
+	}
+}
+
+// codeSpan wraps s in a code span, using more backticks than any run in s
+func codeSpan(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		// A space keeps backticks at the edges apart from the fence
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
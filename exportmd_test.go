@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestExportMarkdown(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "export.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, err := parseOptions(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	exportMarkdown(&got, options)
+	golden := filepath.Join("testdata", "export.md")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("exportMarkdown() =\n%s\nwant\n%s", got.Bytes(), want)
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"docker ps", "`docker ps`"},
+		{"echo `date`", "`` echo `date` ``"},
+		{"a ``b``", "``` a ``b`` ```"},
+	}
+	for _, tt := range tests {
+		if got := codeSpan(tt.in); got != tt.want {
+			t.Errorf("codeSpan(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configKeyOrder ranks the keys of configs, options and args in the order
+// their fields are declared, so formatted options read title, details, command
+func configKeyOrder() map[string]int {
+	order := make(map[string]int)
+	for _, t := range []reflect.Type{reflect.TypeOf(Config{}), reflect.TypeOf(Option{}), reflect.TypeOf(Arg{}), reflect.TypeOf(themeConfig{})} {
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if _, seen := order[name]; !seen && name != "" && name != "-" {
+				order[name] = len(order)
+			}
+		}
+	}
+	return order
+}
+
+// formatConfig re-emits a config as canonical JSON with a 2-space indent. Known
+// keys come in the order of their fields and unknown ones after them sorted,
+// so nothing is dropped. The config has to parse first.
+func formatConfig(data []byte) ([]byte, error) {
+	if _, err := parseConfig(data); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimLeft(data, " \t\r\n\ufeff")))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, value, configKeyOrder(), ""); err != nil {
+		return nil, err
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+// writeJSON writes value indented below indent, ordering object keys by order
+func writeJSON(b *bytes.Buffer, value any, order map[string]int, indent string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			rankI, knownI := order[keys[i]]
+			rankJ, knownJ := order[keys[j]]
+			if knownI && knownJ {
+				return rankI < rankJ
+			}
+			if knownI || knownJ {
+				return knownI
+			}
+			return keys[i] < keys[j]
+		})
+
+		b.WriteString("{\n")
+		for i, key := range keys {
+			b.WriteString(indent + "  ")
+			if err := writeJSONScalar(b, key); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := writeJSON(b, v[key], order, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+		return nil
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, item := range v {
+			b.WriteString(indent + "  ")
+			if err := writeJSON(b, item, order, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "]")
+		return nil
+	}
+	return writeJSONScalar(b, value)
+}
+
+// writeJSONScalar writes a string, number, bool or null, leaving characters
+// like & in commands alone instead of escaping them for HTML
+func writeJSONScalar(b *bytes.Buffer, value any) error {
+	var scalar bytes.Buffer
+	encoder := json.NewEncoder(&scalar)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	b.Write(bytes.TrimSuffix(scalar.Bytes(), []byte("\n")))
+	return nil
+}
+
+// runFormat formats the config file at path, printing it to out or with write
+// replacing the file when its formatting changed
+func runFormat(path string, write bool, out io.Writer) error {
+	if isRemoteConfig(path) {
+		return fmt.Errorf("can't format %s, --fmt needs a local file", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %v", path, err)
+	}
+	formatted, err := formatConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if !write {
+		_, err := out.Write(formatted)
+		return err
+	}
+	if bytes.Equal(data, formatted) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", path, err)
+	}
+	return nil
+}
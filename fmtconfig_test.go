@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatConfig(t *testing.T) {
+	messy := "\ufeff  {\"vars\":{\"B\":\"2\",\"A\":\"1\"},\"options\":[{\"command\":\"make && make install\",\"title\":\"Build\",\"zzz\":1,\"details\":\"Builds it\",\"aaa\":true},\n\t{\"children\":[],\"title\":\"Empty\"}],\"theme\":{\"border\":\"gray\",\"highlight\":\"teal\"}}"
+	want := `{
+  "options": [
+    {
+      "title": "Build",
+      "details": "Builds it",
+      "command": "make && make install",
+      "aaa": true,
+      "zzz": 1
+    },
+    {
+      "title": "Empty",
+      "children": []
+    }
+  ],
+  "theme": {
+    "highlight": "teal",
+    "border": "gray"
+  },
+  "vars": {
+    "A": "1",
+    "B": "2"
+  }
+}
+`
+	got, err := formatConfig([]byte(messy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("formatConfig() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Formatting is stable
+	again, err := formatConfig(got)
+	if err != nil || !bytes.Equal(again, got) {
+		t.Errorf("formatting the formatted config changed it: %v\n%s", err, again)
+	}
+
+	if _, err := formatConfig([]byte(`[{"title": "Build",}]`)); err == nil {
+		t.Errorf("formatConfig() of invalid JSON succeeded")
+	}
+}
+
+func TestRunFormatWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := os.WriteFile(path, []byte(`[{"command": "ls", "title": "List"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runFormat(path, false, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  {\n    \"title\": \"List\",\n    \"command\": \"ls\"\n  }\n]\n"
+	if out.String() != want {
+		t.Errorf("runFormat() printed %q, want %q", out.String(), want)
+	}
+
+	if err := runFormat(path, true, &out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("runFormat(write) wrote %q, want %q", data, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("runFormat(write) changed the file mode: %v, %v", info.Mode(), err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveMenuPath finds the menu a "/" separated path like "Git/Branch" leads
+// to from root, titles matched ignoring case. It's returned as a visit with
+// the menus above it stacked for going back. A path ending in an option that
+// isn't a category leads to its menu, with the option's title to select.
+func resolveMenuPath(root []Option, path string, rootTitle string) (visit menuVisit, selected string, err error) {
+	visit = rootVisit(root, rootTitle)
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return visit, "", nil
+	}
+
+	titles := strings.Split(path, "/")
+	for i, title := range titles {
+		title = strings.TrimSpace(title)
+		option, found := findTitle(visit.Options, title)
+		if !found {
+			return menuVisit{}, "", fmt.Errorf("no option %q in %s", title, visit.Title)
+		}
+		if len(option.Children) == 0 {
+			if i < len(titles)-1 || option.ChildrenCmd != "" {
+				return menuVisit{}, "", fmt.Errorf("%s has no menu to go to", joinPath(visit.Path, option.Title))
+			}
+			return visit, option.Title, nil
+		}
+		visit.Stack = append(visit.Stack, visit.Options)
+		visit.Options = option.Children
+		visit.Title = option.Title
+		visit.Path = joinPath(visit.Path, option.Title)
+	}
+	return visit, "", nil
+}
+
+// findTitle finds the option in options titled title, an exact match wins over
+// one differing in case
+func findTitle(options []Option, title string) (Option, bool) {
+	for _, opt := range options {
+		if opt.Title == title && !opt.Separator {
+			return opt, true
+		}
+	}
+	for _, opt := range options {
+		if strings.EqualFold(opt.Title, title) && !opt.Separator {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestResolveMenuPath(t *testing.T) {
+	root := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Branch", Children: []Option{{Title: "Delete", Command: "git branch -d"}}},
+			{Title: "Status", Command: "git status"},
+			{Title: "Remotes", ChildrenCmd: "git remote"},
+		}},
+		{Title: "git", Command: "git"},
+	}
+
+	tests := []struct {
+		path     string
+		title    string
+		depth    int
+		selected string
+	}{
+		{"", "Menu", 0, ""},
+		{"/", "Menu", 0, ""},
+		{"Git/Branch", "Branch", 2, ""},
+		{" /GIT/branch/ ", "Branch", 2, ""},
+		{"Git/Status", "Git", 1, "Status"},
+		// An exact match wins over one differing in case
+		{"git", "Menu", 0, "git"},
+	}
+	for _, tt := range tests {
+		visit, selected, err := resolveMenuPath(root, tt.path, "Menu")
+		if err != nil {
+			t.Errorf("resolveMenuPath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if visit.Title != tt.title || len(visit.Stack) != tt.depth || selected != tt.selected {
+			t.Errorf("resolveMenuPath(%q) = %q with %d menus above, selecting %q, want %q with %d, selecting %q",
+				tt.path, visit.Title, len(visit.Stack), selected, tt.title, tt.depth, tt.selected)
+		}
+	}
+
+	for _, path := range []string{"Git/Nope", "Git/Status/More", "Git/Remotes"} {
+		if _, _, err := resolveMenuPath(root, path, "Menu"); err == nil {
+			t.Errorf("resolveMenuPath(%q) succeeded, want an error", path)
+		}
+	}
+}
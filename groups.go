@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// Values of the config's categoryOrder
+const (
+	categoriesFirst = "first"
+	categoriesLast  = "last"
+)
+
+// Title of the separator between the categories and the leaves of a grouped menu
+const groupSeparator = "────────"
+
+// checkCategoryOrder validates the config's categoryOrder, empty keeps menus as configured
+func checkCategoryOrder(order string) error {
+	switch order {
+	case "", categoriesFirst, categoriesLast:
+		return nil
+	}
+	return fmt.Errorf("categoryOrder has to be %q or %q, got %q", categoriesFirst, categoriesLast, order)
+}
+
+// isCategory reports whether option is listed as a category
+func isCategory(option Option) bool {
+	return len(option.Children) > 0 || option.ChildrenCmd != "" || isEmptyCategory(option)
+}
+
+// groupByKind moves a menu's categories before its leaves, or after them for
+// categoriesLast, with a separator between the two groups. Menus with
+// separators of their own are left as they were arranged.
+func groupByKind(options []Option, order string) []Option {
+	if order == "" {
+		return options
+	}
+	var categories, leaves []Option
+	for _, opt := range options {
+		if opt.Separator {
+			return options
+		}
+		if isCategory(opt) {
+			categories = append(categories, opt)
+		} else {
+			leaves = append(leaves, opt)
+		}
+	}
+	if len(categories) == 0 || len(leaves) == 0 {
+		return options
+	}
+
+	first, last := categories, leaves
+	if order == categoriesLast {
+		first, last = leaves, categories
+	}
+	grouped := make([]Option, 0, len(options)+1)
+	grouped = append(grouped, first...)
+	grouped = append(grouped, Option{Title: groupSeparator, Separator: true})
+	return append(grouped, last...)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+)
+
+// resolveHomeDir finds the user's home directory through os.UserHomeDir, then
+// $HOME, then the passwd database, which still works in containers without HOME
+func resolveHomeDir(userHomeDir func() (string, error), getenv func(string) string, currentUser func() (*user.User, error)) (string, error) {
+	if dir, err := userHomeDir(); err == nil && dir != "" {
+		return dir, nil
+	}
+	if dir := getenv("HOME"); dir != "" {
+		return dir, nil
+	}
+	u, err := currentUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: HOME is not set and user lookup failed: %v", err)
+	}
+	if u.HomeDir == "" {
+		return "", errors.New("failed to find home directory: HOME is not set and user " + u.Username + " has none")
+	}
+	return u.HomeDir, nil
+}
+
+// findHomeDir is the user's home directory, see resolveHomeDir
+func findHomeDir() (string, error) {
+	return resolveHomeDir(os.UserHomeDir, os.Getenv, user.Current)
+}
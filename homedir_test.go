@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"os/user"
+	"strings"
+	"testing"
+)
+
+func TestResolveHomeDir(t *testing.T) {
+	noHome := func() (string, error) { return "", errors.New("$HOME is not defined") }
+	tests := []struct {
+		name        string
+		userHomeDir func() (string, error)
+		home        string
+		user        *user.User
+		userErr     error
+		want        string
+		wantErr     string
+	}{
+		{"from os", func() (string, error) { return "/home/me", nil }, "/home/other", nil, nil, "/home/me", ""},
+		{"from HOME", noHome, "/home/env", nil, nil, "/home/env", ""},
+		// HOME unset, like in a bare container
+		{"from passwd", noHome, "", &user.User{Username: "ci", HomeDir: "/var/lib/ci"}, nil, "/var/lib/ci", ""},
+		{"lookup failed", noHome, "", nil, errors.New("unknown userid 1001"), "", "unknown userid 1001"},
+		{"no home in passwd", noHome, "", &user.User{Username: "nobody"}, nil, "", "user nobody has none"},
+	}
+	for _, tt := range tests {
+		getenv := func(name string) string {
+			if name == "HOME" {
+				return tt.home
+			}
+			return ""
+		}
+		currentUser := func() (*user.User, error) { return tt.user, tt.userErr }
+
+		got, err := resolveHomeDir(tt.userHomeDir, getenv, currentUser)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: resolveHomeDir() = %q, %v, want an error mentioning %q", tt.name, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: resolveHomeDir() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
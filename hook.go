@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+)
+
+// hookEnv is environ with the selected option's title and "/" separated path
+// added for the onSelect hook
+func hookEnv(environ []string, option Option, path string) []string {
+	return append(slices.Clip(environ), "TALIAS_TITLE="+option.Title, "TALIAS_PATH="+path)
+}
+
+// startHook starts the onSelect hook through sh and doesn't wait for it. Its
+// output is dropped, so it can't end up in the command read by the shell
+// wrapper, and whatever it does never holds up the selection.
+func startHook(command string, env []string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = env
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start onSelect hook: %v", err)
+	}
+	go cmd.Wait()
+	return nil
+}
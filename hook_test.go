@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHookEnv(t *testing.T) {
+	environ := make([]string, 1, 4)
+	environ[0] = "PATH=/usr/bin"
+	option := Option{Title: "Docker Down", Path: "Docker", Command: "docker compose down"}
+
+	env := hookEnv(environ, option, joinPath(option.Path, option.Title))
+	want := []string{"PATH=/usr/bin", "TALIAS_TITLE=Docker Down", "TALIAS_PATH=Docker/Docker Down"}
+	if !slices.Equal(env, want) {
+		t.Errorf("hookEnv() = %q, want %q", env, want)
+	}
+	// Appending never writes into the caller's environ
+	if other := append(environ, "X=1"); other[1] != "X=1" || env[1] != "TALIAS_TITLE=Docker Down" {
+		t.Errorf("hookEnv() shares its array with environ")
+	}
+}
+
+func TestStartHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook")
+	option := Option{Title: "Deploy", Path: "Ops/Prod", Command: "make deploy"}
+	env := hookEnv([]string{"PATH=" + os.Getenv("PATH")}, option, joinPath(option.Path, option.Title))
+
+	// The hook runs in the background, the selection doesn't wait for it
+	start := time.Now()
+	err := startHook(`sleep 0.2; printf '%s|%s' "$TALIAS_TITLE" "$TALIAS_PATH" >`+out, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("startHook() waited %v for the hook", elapsed)
+	}
+
+	var got []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if got, err = os.ReadFile(out); err == nil && len(got) > 0 {
+			break
+		}
+	}
+	if string(got) != "Deploy|Ops/Prod/Deploy" {
+		t.Errorf("hook saw %q, want the option's title and path", got)
+	}
+
+	// A failing hook isn't the selection's problem
+	if err := startHook("exit 3", env); err != nil {
+		t.Errorf("startHook() of a failing hook = %v, want nil", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Bumped whenever Option or the index texts change, so caches written by an
+// older talias are rebuilt rather than decoded with missing fields
+const indexCacheVersion = 1
+
+// indexCache is the search index saved between runs, valid while Key matches
+// the config files it was built from. It's gob encoded, decoding JSON took
+// longer than flattening the options again.
+type indexCache struct {
+	Key     string
+	Options []Option // flattened, with their Path and Source
+	Texts   []cachedText
+}
+
+// cachedText is an indexedText with exported fields for gob
+type cachedText struct {
+	Title       string
+	TitleLength int
+	Aliases     []string
+	Path        string
+	PathDetails string
+}
+
+// indexKey hashes the names, sizes and modification times of files together
+// with settings that change the tree, so editing any of them invalidates the index
+func indexKey(files []string, settings string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "v%d\x00", indexCacheVersion)
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00", file)
+		if info, err := os.Stat(file); err == nil {
+			fmt.Fprintf(hash, "%d\x00%d\x00", info.Size(), info.ModTime().UnixNano())
+		} else {
+			fmt.Fprint(hash, "missing\x00")
+		}
+	}
+	fmt.Fprint(hash, settings)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// loadIndex reads the index saved under key, false when there is no index or
+// it was built from a different config
+func loadIndex(filename string, key string) (*optionIndex, bool) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	var cache indexCache
+	if err := gob.NewDecoder(file).Decode(&cache); err != nil || cache.Key != key || len(cache.Texts) != len(cache.Options) {
+		return nil, false
+	}
+	texts := make([]indexedText, len(cache.Texts))
+	for i, text := range cache.Texts {
+		texts[i] = indexedText{
+			title:       text.Title,
+			titleLength: text.TitleLength,
+			aliases:     text.Aliases,
+			path:        text.Path,
+			pathDetails: text.PathDetails,
+		}
+	}
+	return &optionIndex{options: cache.Options, texts: texts}, true
+}
+
+// saveIndex writes the index for the config identified by key
+func saveIndex(filename string, key string, index *optionIndex) error {
+	cache := indexCache{Key: key, Options: index.options, Texts: make([]cachedText, len(index.texts))}
+	for i, text := range index.texts {
+		cache.Texts[i] = cachedText{
+			Title:       text.title,
+			TitleLength: text.titleLength,
+			Aliases:     text.aliases,
+			Path:        text.path,
+			PathDetails: text.pathDetails,
+		}
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(cache); err != nil {
+		return fmt.Errorf("failed to encode index: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", filename, err)
+	}
+	if err := os.WriteFile(filename, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", filename, err)
+	}
+	return nil
+}
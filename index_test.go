@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndexKey(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "options.json")
+	templates := filepath.Join(dir, "templates.json")
+	if err := os.WriteFile(config, []byte(`{"options": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{config, templates}
+	key := indexKey(files, "prune-empty=false")
+
+	if got := indexKey(files, "prune-empty=false"); got != key {
+		t.Errorf("key changed without any change to the config")
+	}
+	if got := indexKey(files, "prune-empty=true"); got == key {
+		t.Errorf("key unchanged after a setting changed")
+	}
+
+	// Created, then edited, both invalidate the index
+	if err := os.WriteFile(templates, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withTemplates := indexKey(files, "prune-empty=false")
+	if withTemplates == key {
+		t.Errorf("key unchanged after templates.json was created")
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(config, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexKey(files, "prune-empty=false"); got == withTemplates {
+		t.Errorf("key unchanged after the config was modified")
+	}
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Ps", Command: "docker ps", Aliases: []string{"containers"}, Source: "/home/me/.talias/options.json"},
+			{Title: "Logs", Command: "docker logs -f ${1:container}", Details: "Follows a container's logs", Tags: []string{"infra"}},
+		}},
+		{Title: "Deploy", Command: "make deploy", Run: true, Children: []Option{{Title: "Staging", Command: "make deploy-staging"}}},
+	}
+	index := newOptionIndex(flattenOptions(options))
+	filename := filepath.Join(t.TempDir(), ".talias", "index.cache")
+	if err := saveIndex(filename, "key", index); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, found := loadIndex(filename, "key")
+	if !found {
+		t.Fatal("loadIndex() found nothing")
+	}
+	if !reflect.DeepEqual(loaded.options, index.options) {
+		t.Errorf("options = %+v, want %+v", loaded.options, index.options)
+	}
+	if !reflect.DeepEqual(loaded.texts, index.texts) {
+		t.Errorf("texts = %+v, want %+v", loaded.texts, index.texts)
+	}
+	settings := searchSettings{MinScore: defaultMinScore, MatchDetails: true}
+	for _, query := range []string{"ps", "containers", "docker log", "follows", "staging"} {
+		got, want := titles(loaded.Search(query, settings)), titles(index.Search(query, settings))
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Search(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	if _, found := loadIndex(filename, "other key"); found {
+		t.Errorf("loadIndex() used an index built from another config")
+	}
+	if _, found := loadIndex(filepath.Join(t.TempDir(), "missing"), "key"); found {
+		t.Errorf("loadIndex() found a missing file")
+	}
+	if err := os.WriteFile(filename, []byte(`{"key": "key"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := loadIndex(filename, "key"); found {
+		t.Errorf("loadIndex() used a corrupt index")
+	}
+}
+
+// BenchmarkIndexCache compares indexing a large config at startup with
+// loading the index saved by an earlier run
+func BenchmarkIndexCache(b *testing.B) {
+	var categories []Option
+	for i := range 300 {
+		category := Option{Title: fmt.Sprintf("Category %d", i)}
+		for j := range 100 {
+			category.Children = append(category.Children, Option{
+				Title:   fmt.Sprintf("Command %d-%d", i, j),
+				Command: fmt.Sprintf("echo %d %d", i, j),
+				Details: "Prints the numbers of its category and itself",
+				Aliases: []string{fmt.Sprintf("c%d", j)},
+			})
+		}
+		categories = append(categories, category)
+	}
+	filename := filepath.Join(b.TempDir(), "index.cache")
+	if err := saveIndex(filename, "key", newOptionIndex(flattenOptions(categories))); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("rebuild", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			newOptionIndex(flattenOptions(categories))
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, found := loadIndex(filename, "key"); !found {
+				b.Fatal("index not found")
+			}
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// verifyChecksum checks data against a hex encoded SHA-256 sum, anything after
+// the first field is ignored so sha256sum output can be used as is
+func verifyChecksum(data []byte, checksum string) error {
+	fields := strings.Fields(checksum)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty checksum")
+	}
+	expected := strings.ToLower(fields[0])
+
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch, expected sha256 %s but config has %s, refusing to load it", expected, actual)
+	}
+	return nil
+}
+
+// sidecarChecksum reads the checksum from filename.sha256, returns "" if there is none
+func sidecarChecksum(filename string) (string, error) {
+	data, err := os.ReadFile(filename + ".sha256")
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum file: %v", err)
+	}
+	return string(data), nil
+}
+
+// parseVerifiedConfig checks data against checksum, when one is given, before parsing it
+func parseVerifiedConfig(data []byte, checksum string) (Config, error) {
+	if checksum != "" {
+		if err := verifyChecksum(data, checksum); err != nil {
+			return Config{}, err
+		}
+	}
+	return parseConfig(data)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte(`[{"title": "Top", "command": "top"}]`)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		data     []byte
+		checksum string
+		wantErr  bool
+	}{
+		{"matching", data, checksum, false},
+		{"uppercase", data, strings.ToUpper(checksum), false},
+		// As written by sha256sum, with the file name after the digest
+		{"sha256sum output", data, checksum + "  options.json\n", false},
+		{"tampered", []byte(`[{"title": "Top", "command": "rm -rf ~"}]`), checksum, true},
+		{"wrong digest", data, strings.Repeat("0", 64), true},
+		{"empty", data, "  \n", true},
+	}
+	for _, tt := range tests {
+		if err := verifyChecksum(tt.data, tt.checksum); (err != nil) != tt.wantErr {
+			t.Errorf("%s: verifyChecksum() = %v, want error %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfigFromFileChecksum(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "options.json")
+	data := []byte(`[{"title": "Top", "command": "top"}]`)
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+
+	// No sidecar means nothing to check
+	checksum, err := sidecarChecksum(filename)
+	if err != nil || checksum != "" {
+		t.Fatalf("sidecarChecksum() without a file = %q, %v", checksum, err)
+	}
+	if err := os.WriteFile(filename+".sha256", []byte(hex.EncodeToString(sum[:])+"  options.json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if checksum, err = sidecarChecksum(filename); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfigFromFile(filename, checksum)
+	if err != nil || len(config.Options) != 1 {
+		t.Errorf("loadConfigFromFile() with a matching sidecar = %v, %v", titles(config.Options), err)
+	}
+
+	if err := os.WriteFile(filename, []byte(`[{"title": "Top", "command": "rm -rf ~"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFromFile(filename, checksum); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("loadConfigFromFile() of a tampered config = %v, want a checksum mismatch", err)
+	}
+}
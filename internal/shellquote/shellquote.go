@@ -0,0 +1,19 @@
+// Package shellquote quotes strings for POSIX shells so commands can be
+// composed from paths and values without breaking on spaces or quotes.
+package shellquote
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Strings made only of these characters mean the same to a shell quoted or not
+var safePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote returns s as a single shell word, an empty string becomes a pair of quotes
+func Quote(s string) string {
+	if safePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,46 @@
+package shellquote
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"ls", "ls"},
+		{"/usr/local/bin/go-1.25", "/usr/local/bin/go-1.25"},
+		{"my file.txt", "'my file.txt'"},
+		{"it's", `'it'\''s'`},
+		{"''", `''\'''\'''`},
+		{"$HOME", "'$HOME'"},
+		{"`whoami`", "'`whoami`'"},
+		{"$(rm -rf /)", "'$(rm -rf /)'"},
+		{"a\nb", "'a\nb'"},
+		{"~/notes", "'~/notes'"},
+		{"*.go", "'*.go'"},
+		{"a;b&c|d", "'a;b&c|d'"},
+		{"\\n", `'\n'`},
+		{"über", "'über'"},
+		{"日本語 テキスト", "'日本語 テキスト'"},
+	}
+	for _, tt := range tests {
+		got := Quote(tt.in)
+		if got != tt.want {
+			t.Errorf("Quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+
+		// The shell has to hand back exactly the original string
+		out, err := exec.Command("sh", "-c", "printf %s "+got).Output()
+		if err != nil {
+			t.Errorf("sh -c with Quote(%q): %v", tt.in, err)
+			continue
+		}
+		if string(out) != tt.in {
+			t.Errorf("sh -c printed %q for Quote(%q), want it unchanged", out, tt.in)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lastArgs holds the argument values last entered for each option, by the
+// option's path and then by arg name
+type lastArgs map[string]map[string]string
+
+// loadLastArgs reads the last entered argument values, a missing file means none yet
+func loadLastArgs(filename string) (lastArgs, error) {
+	last := make(lastArgs)
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return last, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, fmt.Errorf("failed to parse last arguments: %v", err)
+	}
+	return last, nil
+}
+
+// saveLastArgs writes the last entered argument values, creating the parent directory if needed
+func saveLastArgs(filename string, last lastArgs) error {
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode last arguments: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", filename, err)
+	}
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", filename, err)
+	}
+	return nil
+}
+
+// remember stores the values entered for the args of the option at path,
+// secret args are never stored
+func (l lastArgs) remember(path string, args []Arg, values map[string]string) {
+	kept := make(map[string]string)
+	for _, arg := range args {
+		if value, ok := values[arg.Name]; ok && !arg.Secret {
+			kept[arg.Name] = value
+		}
+	}
+	if len(kept) == 0 {
+		delete(l, path)
+		return
+	}
+	l[path] = kept
+}
+
+// value is the last value entered for arg of the option at path, or its default
+func (l lastArgs) value(path string, arg Arg) string {
+	if value, ok := l[path][arg.Name]; ok && !arg.Secret {
+		return value
+	}
+	return arg.Default
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLastArgs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".talias", "args.json")
+	args := []Arg{
+		{Name: "host", Default: "localhost"},
+		{Name: "port", Default: "22"},
+		{Name: "password", Secret: true},
+	}
+
+	// Nothing saved yet, the defaults are used
+	last, err := loadLastArgs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := last.value("SSH", args[0]); got != "localhost" {
+		t.Errorf("value(host) before saving = %q, want the default", got)
+	}
+
+	last.remember("SSH", args, map[string]string{"host": "web1", "port": "2222", "password": "hunter2"})
+	if err := saveLastArgs(filename, last); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "password") {
+		t.Errorf("saved file has the secret arg: %s", data)
+	}
+
+	restored, err := loadLastArgs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		arg  Arg
+		want string
+	}{
+		{"SSH", args[0], "web1"},
+		{"SSH", args[1], "2222"},
+		{"SSH", args[2], ""},
+		{"Other", args[0], "localhost"},
+	}
+	for _, tt := range tests {
+		if got := restored.value(tt.path, tt.arg); got != tt.want {
+			t.Errorf("value(%s, %s) = %q, want %q", tt.path, tt.arg.Name, got, tt.want)
+		}
+	}
+
+	// A secret written into the file by hand still isn't filled in
+	restored["SSH"]["password"] = "hunter2"
+	if got := restored.value("SSH", args[2]); got != "" {
+		t.Errorf("value(password) = %q, want secrets never restored", got)
+	}
+}
+
+func TestLoadLastArgsInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "args.json")
+	if err := os.WriteFile(filename, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadLastArgs(filename); err == nil {
+		t.Error("loadLastArgs() accepted invalid JSON")
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+// lastRun remembers the last executed option for running it again with '.'
+type lastRun struct {
+	option  *Option
+	command string
+}
+
+// Record remembers option ran as command, the command with its parameters
+// filled in. Values of secret arguments aren't kept, they're asked for again.
+func (l *lastRun) Record(option Option, command string) {
+	if hasSecretArgs(option) {
+		command = option.Command
+	}
+	l.option, l.command = &option, command
+}
+
+// Rerun hands the last run to execute, or to prompt when its parameters still
+// have to be filled in. It returns false when nothing ran yet.
+func (l *lastRun) Rerun(execute func(Option, string), prompt func(Option)) bool {
+	if l.option == nil {
+		return false
+	}
+	if len(parseParameters(l.command)) > 0 {
+		prompt(*l.option)
+		return true
+	}
+	execute(*l.option, l.command)
+	return true
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestLastRun(t *testing.T) {
+	var executed, prompted []string
+	execute := func(option Option, command string) { executed = append(executed, command) }
+	prompt := func(option Option) { prompted = append(prompted, option.Title) }
+	reset := func() { executed, prompted = nil, nil }
+
+	var last lastRun
+	if last.Rerun(execute, prompt) {
+		t.Error("Rerun() ran something before anything was recorded")
+	}
+
+	// The command runs again with the parameters it was given
+	deploy := Option{Title: "Deploy", Command: "deploy ${1:env}"}
+	last.Record(deploy, "deploy prod")
+	if !last.Rerun(execute, prompt) || len(executed) != 1 || executed[0] != "deploy prod" || prompted != nil {
+		t.Errorf("Rerun() executed %q, prompted %q, want deploy prod executed", executed, prompted)
+	}
+
+	// The latest run replaces the one before
+	reset()
+	last.Record(Option{Title: "Status", Command: "git status"}, "git status")
+	if last.Rerun(execute, prompt); len(executed) != 1 || executed[0] != "git status" {
+		t.Errorf("Rerun() executed %q, want git status", executed)
+	}
+
+	// Secret values aren't kept, the prompt asks for them again
+	reset()
+	login := Option{
+		Title:   "Login",
+		Command: "login --token ${1:token}",
+		Args:    []Arg{{Name: "token", Secret: true}},
+	}
+	last.Record(login, "login --token hunter2")
+	if last.Rerun(execute, prompt); executed != nil || len(prompted) != 1 || prompted[0] != "Login" {
+		t.Errorf("Rerun() executed %q, prompted %q, want Login prompted", executed, prompted)
+	}
+	if last.command != login.Command {
+		t.Errorf("kept command %q, want the placeholders only", last.command)
+	}
+}
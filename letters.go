@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// letterEntry is a letter of the jump list and the first item starting with it
+type letterEntry struct {
+	Letter rune
+	Index  int
+}
+
+// firstLetter is the uppercased first letter or digit of a title
+func firstLetter(title string) (rune, bool) {
+	for _, r := range title {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r), true
+		}
+		if !unicode.IsSpace(r) && !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+			break
+		}
+	}
+	return 0, false
+}
+
+// letterIndex lists the distinct first letters of a menu's titles in
+// alphabetical order, options that can't be selected aren't jumped to
+func letterIndex(options []Option) []letterEntry {
+	seen := make(map[rune]bool)
+	var entries []letterEntry
+	for i, opt := range options {
+		if opt.Disabled || isEmptyCategory(opt) || opt.Separator {
+			continue
+		}
+		letter, ok := firstLetter(opt.Title)
+		if !ok || seen[letter] {
+			continue
+		}
+		seen[letter] = true
+		entries = append(entries, letterEntry{Letter: letter, Index: i})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Letter < entries[j].Letter
+	})
+	return entries
+}
+
+// letterTarget resolves a typed letter to the index of the first item starting with it
+func letterTarget(entries []letterEntry, letter rune) (int, bool) {
+	letter = unicode.ToUpper(letter)
+	for _, entry := range entries {
+		if entry.Letter == letter {
+			return entry.Index, true
+		}
+	}
+	return 0, false
+}
+
+// renderLetters draws the jump list one letter per line, highlighting current
+func renderLetters(entries []letterEntry, current rune) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		if entry.Letter == current {
+			b.WriteString("[::r]" + string(entry.Letter) + "[::-]\n")
+		} else {
+			b.WriteString(string(entry.Letter) + "\n")
+		}
+	}
+	return b.String()
+}
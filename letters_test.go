@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFirstLetter(t *testing.T) {
+	tests := []struct {
+		title  string
+		want   rune
+		wantOK bool
+	}{
+		{"docker", 'D', true},
+		{"  [Build]", 'B', true},
+		{"9 lives", '9', true},
+		{"élan", 'É', true},
+		{"---", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := firstLetter(tt.title)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("firstLetter(%q) = %q, %v, want %q, %v", tt.title, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLetterIndex(t *testing.T) {
+	options := []Option{
+		{Title: "kubectl", Command: "kubectl"},
+		{Title: "Docker", Command: "docker"},
+		{Title: "deploy", Command: "make deploy"},
+		{Separator: true, Title: "Alpha"},
+		{Title: "Archived", Command: "true", Disabled: true},
+		{Title: "Empty", Children: []Option{}},
+		{Title: "build", Command: "make"},
+	}
+	want := []letterEntry{{'B', 6}, {'D', 1}, {'K', 0}}
+	entries := letterIndex(options)
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("letterIndex() = %v, want %v", entries, want)
+	}
+
+	tests := []struct {
+		letter rune
+		want   int
+		wantOK bool
+	}{
+		{'d', 1, true},
+		{'K', 0, true},
+		{'a', 0, false},
+		{'e', 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := letterTarget(entries, tt.letter)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("letterTarget(%q) = %d, %v, want %d, %v", tt.letter, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if got, want := renderLetters(entries, 'D'), "B\n[::r]D[::-]\nK\n"; got != want {
+		t.Errorf("renderLetters() = %q, want %q", got, want)
+	}
+}
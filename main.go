@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -15,8 +23,171 @@ import (
 type Option struct {
   Title    string   `json:"title"`
   Details  string   `json:"details"`
+  Subtitle string   `json:"subtitle,omitempty"` // short grey line under the title in the list
   Command  string   `json:"command"`
   Children []Option `json:"children,omitempty"`
+  Notify   bool     `json:"notify,omitempty"`  // desktop notification when run with --exec finishes
+  KeepOpen bool     `json:"keepOpen,omitempty"` // come back to the menu after running with --exec, like --loop
+  Hotkey   string   `json:"hotkey,omitempty"`   // single key that activates the option in its menu
+  Run      bool     `json:"run,omitempty"`      // Enter runs the command even with children, the open key shows them
+  Confirm  bool     `json:"confirm,omitempty"`  // ask before running the command
+
+  // ConfirmPhrase has to be typed exactly before the command can run, e.g. the
+  // name of the cluster it deletes from
+  ConfirmPhrase string `json:"confirmPhrase,omitempty"`
+
+  // CopyOutput copies the command's stdout to the clipboard instead of
+  // printing it, with --exec
+  CopyOutput bool `json:"copyOutput,omitempty"`
+
+  // DefaultChild is the title of the option selected when the category opens,
+  // the first one is selected if there's no such option
+  DefaultChild string `json:"defaultChild,omitempty"`
+
+  // Note is for whoever maintains the config, only --check and the debug view show it
+  Note string `json:"note,omitempty"`
+
+  // When hides the option and everything below it unless a variable is set,
+  // "NAME" needs it to be set and not empty, "NAME=value" to have that value
+  When string `json:"when,omitempty"`
+
+  // Separator options are headings between the options of a menu, they can't
+  // be selected and search skips them
+  Separator bool `json:"separator,omitempty"`
+  Chord    string   `json:"chord,omitempty"`    // space separated keys that run the option from any menu, e.g. "g b d"
+
+  // PostMessage is shown once the command succeeded with --exec, e.g. where to check the result
+  PostMessage string `json:"postMessage,omitempty"`
+  Aliases  []string `json:"aliases,omitempty"` // extra names search matches, never displayed
+  Tags     []string `json:"tags,omitempty"`    // labels like "infra" for --tag, a category's count for everything below it
+
+  // ExitCode is talias' exit status after printing the command, for wrappers
+  // that branch on the selection, ignored with --exec
+  ExitCode int `json:"exitCode,omitempty"`
+
+  // TimeoutSeconds kills the command after this long when run with --exec, 0 means no timeout
+  TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+  NoHistory bool   `json:"noHistory,omitempty"` // never record this option in stats.json
+  Use       string `json:"use,omitempty"`       // name of a template in the config's templates to inherit fields from
+  Shell     string `json:"shell,omitempty"`     // shell to run the command with instead of the default
+
+  // Disabled options are shown dimmed but can't be run, DisabledReason says why
+  Disabled       bool   `json:"disabled,omitempty"`
+  DisabledReason string `json:"disabledReason,omitempty"`
+
+  // DetailsFormat is "markdown" to render details as markdown, "template" to
+  // render them as a template of the option's fields, plain text otherwise
+  DetailsFormat string `json:"detailsFormat,omitempty"`
+
+  // DetailsCmd prints more details shown below Details. It runs in the
+  // background once the option is selected, at most once per session, and is
+  // killed after DetailsTimeoutSeconds (5 by default).
+  DetailsCmd            string `json:"detailsCmd,omitempty"`
+  DetailsTimeoutSeconds int    `json:"detailsTimeoutSeconds,omitempty"`
+
+  // ChildrenCmd prints the options of a menu whose children are generated,
+  // RefreshSeconds runs it again on an interval while the menu is open
+  ChildrenCmd    string `json:"childrenCmd,omitempty"`
+  RefreshSeconds int    `json:"refreshSeconds,omitempty"`
+
+  // EditFile is a file to open in $EDITOR, or vi, instead of running a
+  // command, the menu comes back once the editor exits
+  EditFile string `json:"editFile,omitempty"`
+
+  // Level is "info", "warn" or "danger", showing the title in the theme's
+  // color for it
+  Level string `json:"level,omitempty"`
+
+  // Args describe the command's ${n:label} placeholders, matched by label
+  Args []Arg `json:"args,omitempty"`
+
+  // Path is the "/" separated breadcrumb of parent titles, set when flattening
+  Path string `json:"-"`
+
+  // Source is the config file, URL or plugin script the option came from
+  Source string `json:"-"`
+}
+
+// Arg describes how to prompt for a placeholder, Secret values are masked
+// while typed and never stored
+type Arg struct {
+	Name     string `json:"name"`
+	Secret   bool   `json:"secret,omitempty"`
+	Default  string `json:"default,omitempty"`  // prefilled value in the argument form
+	Required bool   `json:"required,omitempty"` // the form can't be submitted while empty
+	Pattern  string `json:"pattern,omitempty"`  // regular expression the whole value has to match
+
+	// OptionsCmd prints the values to pick from, one per line, instead of typing one
+	OptionsCmd string `json:"optionsCmd,omitempty"`
+}
+
+// validateArg checks a value entered for arg, an empty optional value is always valid
+func validateArg(arg Arg, value string) error {
+	if value == "" {
+		if arg.Required {
+			return fmt.Errorf("%s is required", arg.Name)
+		}
+		return nil
+	}
+	if arg.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile("^(?:" + arg.Pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid pattern for %s: %v", arg.Name, err)
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("%s must match %s", arg.Name, arg.Pattern)
+	}
+	return nil
+}
+
+// editCommand is option with its command replaced by text as edited before
+// running it, false when nothing is left to run
+func editCommand(option Option, text string) (Option, bool) {
+	option.Command = strings.TrimSpace(text)
+	return option, option.Command != ""
+}
+
+// argFor finds the Arg describing the placeholder with the given label
+func argFor(option Option, label string) (Arg, bool) {
+	for _, arg := range option.Args {
+		if arg.Name == label {
+			return arg, true
+		}
+	}
+	return Arg{}, false
+}
+
+// argumentValues checks the values entered in the argument form, one per
+// parameter, and maps them to their placeholders and, for next time, to their
+// arg names. A failed check returns the index of the field to fix.
+func argumentValues(option Option, parameters []Parameter, entered []string) (map[string]string, map[string]string, int, error) {
+	values := make(map[string]string)
+	byName := make(map[string]string)
+	for i, param := range parameters {
+		arg, ok := argFor(option, param.Label)
+		if !ok {
+			arg = Arg{Name: param.Label}
+		}
+		if err := validateArg(arg, entered[i]); err != nil {
+			return nil, nil, i, err
+		}
+		values[param.Placeholder] = entered[i]
+		byName[arg.Name] = entered[i]
+	}
+	return values, byName, 0, nil
+}
+
+// hasSecretArgs reports whether any placeholder of the option takes a secret value
+func hasSecretArgs(option Option) bool {
+	for _, param := range parseParameters(option.Command) {
+		if arg, ok := argFor(option, param.Label); ok && arg.Secret {
+			return true
+		}
+	}
+	return false
 }
 
 type Parameter struct {
@@ -51,21 +222,39 @@ func parseParameters(command string) []Parameter {
 	return parameters
 }
 
-func loadOptionsFromFile(filename string) ([]Option, error) {
+// fillParameters replaces each parameter's placeholder in command with its
+// value, parameters without a value are left as they are
+func fillParameters(command string, parameters []Parameter, values map[string]string) string {
+	for _, param := range parameters {
+		if value, exists := values[param.Placeholder]; exists {
+			command = strings.ReplaceAll(command, param.Placeholder, value)
+		}
+	}
+	return command
+}
+
+// loadConfigFromFile reads and parses a config, verifying its SHA-256 checksum when one is given
+func loadConfigFromFile(filename string, checksum string) (Config, error) {
 	// Read the file
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+		return Config{}, fmt.Errorf("failed to read file %s: %v", filename, err)
 	}
 
-	// Parse JSON
-	var options []Option
-	err = json.Unmarshal(data, &options)
+	config, err := parseVerifiedConfig(data, checksum)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+		return Config{}, fmt.Errorf("%s: %v", filename, err)
 	}
+	return config, nil
+}
 
-	return options, nil
+// parseOptions decodes the options of a JSON config in either form, see parseConfig
+func parseOptions(data []byte) ([]Option, error) {
+	config, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return config.Options, nil
 }
 
 func containsOption(options []Option, target []Option) bool {
@@ -81,37 +270,451 @@ func containsOption(options []Option, target []Option) bool {
 }
 
 func flattenOptions(options []Option) []Option {
+	return flattenOptionsUnder(options, "")
+}
+
+// flattenOptionsUnder flattens options whose parents form the given path
+func flattenOptionsUnder(options []Option, path string) []Option {
 	var result []Option
 	for _, opt := range options {
 		if len(opt.Children) > 0 {
-			// Only add children, skip the parent
-			result = append(result, flattenOptions(opt.Children)...)
-		} else {
+			// Only add children, skip the parent unless it runs its own command
+			if opt.Run && opt.Command != "" {
+				opt.Path = path
+				result = append(result, opt)
+			}
+			result = append(result, flattenOptionsUnder(opt.Children, joinPath(path, opt.Title))...)
+		} else if !isEmptyCategory(opt) && opt.ChildrenCmd == "" && !opt.Separator {
 			// Add leaf nodes (items with commands)
+			opt.Path = path
 			result = append(result, opt)
 		}
 	}
 	return result
 }
 
-func fuzzySearch(query string, options []Option) []Option {
+// isEmptyCategory reports whether an option was written as a category with
+// "children": [] and has no command to fall back on, an empty children array
+// with a command is just a leaf
+func isEmptyCategory(option Option) bool {
+	return option.Children != nil && len(option.Children) == 0 && isBlankCommand(option.Command)
+}
+
+// opensOnEnter reports whether Enter opens an option rather than running it.
+// Options with children are categories unless marked run, anything else
+// (including "children": [] with a command) runs its command.
+func opensOnEnter(option Option) bool {
+	runs := option.Run && option.Command != ""
+	return (len(option.Children) > 0 || option.ChildrenCmd != "") && !runs
+}
+
+// joinPath appends title to a "/" separated breadcrumb path
+func joinPath(path string, title string) string {
+	if path == "" {
+		return title
+	}
+	return path + "/" + title
+}
+
+// breadcrumb is the full path of an option. Options of the menu being shown
+// weren't flattened and don't know their parents, menuPath is used for them.
+func breadcrumb(option Option, menuPath string) string {
+	if option.Path != "" {
+		return joinPath(option.Path, option.Title)
+	}
+	return joinPath(menuPath, option.Title)
+}
+
+// parentPath drops the last title from a breadcrumb path
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// Default minimum score a fuzzy match needs to be shown, queries shorter than
+// shortQueryLength runes get a proportionally lower threshold
+const (
+	defaultMinScore  = 20
+	shortQueryLength = 3
+)
+
+// fuzzyScore rates how well query matches text (both lowercase), 0 means no match
+func fuzzyScore(query string, text string) int {
 	if query == "" {
-		return options
+		return 0
 	}
-	
-	var results []Option
+	if text == query {
+		return 100
+	}
+	if strings.HasPrefix(text, query) {
+		return 90
+	}
+	if index := strings.Index(text, query); index >= 0 {
+		// Matches starting on a word boundary rank above mid-word matches
+		if strings.ContainsRune(" -_/.", rune(text[index-1])) {
+			return 75
+		}
+		return 60
+	}
+
+	// Subsequence match, penalised by how spread out the matched runes are.
+	// Runes are decoded in place, this runs for every option on every keystroke.
+	rest := query
+	start, end := -1, -1
+	i := 0
+	for _, r := range text {
+		if rest == "" {
+			break
+		}
+		if next, size := utf8.DecodeRuneInString(rest); r == next {
+			if start < 0 {
+				start = i
+			}
+			end = i
+			rest = rest[size:]
+		}
+		i++
+	}
+	if rest != "" {
+		return 0
+	}
+	gaps := (end - start + 1) - utf8.RuneCountInString(query)
+	return max(40-gaps*5, 1)
+}
+
+// effectiveMinScore relaxes minScore for short queries so they don't hide everything
+func effectiveMinScore(query string, minScore int) int {
+	length := len([]rune(query))
+	if length < shortQueryLength {
+		return minScore * length / shortQueryLength
+	}
+	return minScore
+}
+
+// filterOptions keeps the options of one menu whose title or aliases match
+// query, in menu order so categories stay where they were
+func filterOptions(options []Option, query string, minScore int) []Option {
 	queryLower := strings.ToLower(query)
-	
+	threshold := max(effectiveMinScore(queryLower, minScore), 1)
+
+	var filtered []Option
 	for _, opt := range options {
-		titleLower := strings.ToLower(opt.Title)
-		
-		// Check if query matches title or details
-		if strings.Contains(titleLower, queryLower) {
-			results = append(results, opt)
+		best := fuzzyScore(queryLower, strings.ToLower(opt.Title))
+		for _, alias := range opt.Aliases {
+			best = max(best, fuzzyScore(queryLower, strings.ToLower(alias)))
+		}
+		if best >= threshold {
+			filtered = append(filtered, opt)
 		}
 	}
-	
-	return results
+	return filtered
+}
+
+// searchSettings tune how optionIndex.Search matches and filters options
+type searchSettings struct {
+	MinScore     int  // results scoring below this are dropped
+	MatchDetails bool // also match query tokens against details
+}
+
+// Default number of search results shown before the rest are summarised in a "more" row
+const defaultMaxResults = 200
+
+// limitResults keeps the first max results and reports how many were left
+// out, max of 0 or less keeps everything
+func limitResults(results []Option, max int) ([]Option, int) {
+	if max <= 0 || len(results) <= max {
+		return results, 0
+	}
+	return results[:max], len(results) - max
+}
+
+// withMoreRow appends a row saying how many results were hidden, it isn't
+// selectable, like a group header
+func withMoreRow(rows []listRow, hidden int, msg messages) []listRow {
+	if hidden <= 0 {
+		return rows
+	}
+	return append(rows, listRow{Text: msg.get("moreResults", hidden)})
+}
+
+// searchResultRows are the list rows for search results, with a "more" row
+// when some were hidden. A query without results gets a "no matches" row
+// instead of a blank list, Enter does nothing on it.
+func searchResultRows(results []Option, hidden int, grouped bool, query string, msg messages) []listRow {
+	if len(results) == 0 && query != "" {
+		return []listRow{{Text: msg.get("noMatches", tview.Escape(query))}}
+	}
+	return withMoreRow(buildSearchRows(results, grouped, msg.get("mainMenu")), hidden, msg)
+}
+
+// dedupeOptions drops options with the same title and command as an earlier
+// one, so run it on ranked results to keep the best scoring location
+func dedupeOptions(options []Option) []Option {
+	seen := make(map[string]bool)
+	var result []Option
+	for _, opt := range options {
+		key := opt.Title + "\x00" + opt.Command
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, opt)
+	}
+	return result
+}
+
+// listRow is one line of the list, rows without an Option are headers and can't be selected
+type listRow struct {
+	Text   string
+	Option *Option
+	Skip   bool // passed over by Up/Down navigation even though it has an option
+}
+
+// Selectable reports whether navigation can stop on the row
+func (r listRow) Selectable() bool {
+	return r.Option != nil && !r.Skip
+}
+
+// optionAt is the option of the row at index, false for headers and indexes
+// past the rows
+func optionAt(rows []listRow, index int) (Option, bool) {
+	if index >= 0 && index < len(rows) && rows[index].Option != nil {
+		return *rows[index].Option, true
+	}
+	return Option{}, false
+}
+
+// buildMenuRows lays out a menu's options as list rows, categories get the
+// number of items in them and disabled options and empty categories are
+// skipped by navigation
+func buildMenuRows(options []Option, recursiveCounts bool) []listRow {
+	rows := make([]listRow, len(options))
+	for i := range options {
+		skip := options[i].Disabled || isEmptyCategory(options[i]) || options[i].Separator
+		text := displayTitle(options[i])
+		if len(options[i].Children) > 0 {
+			text += fmt.Sprintf(" [gray](%d)[-]", childCount(options[i], recursiveCounts))
+		}
+		rows[i] = listRow{Text: text, Option: &options[i], Skip: skip}
+	}
+	return rows
+}
+
+// defaultChildIndex finds the row of the option titled title, rows that can't
+// be selected don't count
+func defaultChildIndex(rows []listRow, title string) (int, bool) {
+	for i, row := range rows {
+		if row.Selectable() && row.Option.Title == title {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// menuHotkeys maps the options' hotkeys to their index in options. Disabled
+// options, keys of global actions and repeats of an earlier hotkey are left out.
+func menuHotkeys(options []Option, global map[string][]rune) map[rune]int {
+	reserved := make(map[rune]bool)
+	for _, keys := range global {
+		for _, key := range keys {
+			reserved[key] = true
+		}
+	}
+
+	hotkeys := make(map[rune]int)
+	for i, opt := range options {
+		if utf8.RuneCountInString(opt.Hotkey) != 1 || opt.Disabled || isEmptyCategory(opt) || opt.Separator {
+			continue
+		}
+		key, _ := utf8.DecodeRuneInString(opt.Hotkey)
+		if _, taken := hotkeys[key]; taken || reserved[key] {
+			continue
+		}
+		hotkeys[key] = i
+	}
+	return hotkeys
+}
+
+// hotkeyCapture is the list's input capture for hotkeys, pressing one selects
+// its option and activates it like Enter. hotkeys returns the current menu's.
+func hotkeyCapture(list *tview.List, hotkeys func() map[rune]int) func(*tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if index, ok := hotkeys()[event.Rune()]; ok && event.Key() == tcell.KeyRune {
+			list.SetCurrentItem(index)
+			return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+		}
+		return event
+	}
+}
+
+// childCount is the number of options directly in a category, or recursively
+// the number of options that can be run anywhere below it
+func childCount(option Option, recursive bool) int {
+	if recursive {
+		return len(flattenOptions(option.Children))
+	}
+	count := 0
+	for _, child := range option.Children {
+		if !child.Separator {
+			count++
+		}
+	}
+	return count
+}
+
+// buildSearchRows lays out search results as list rows, when grouped the results
+// are gathered under a header per parent path in order of each group's best match
+func buildSearchRows(results []Option, grouped bool, rootTitle string) []listRow {
+	var rows []listRow
+	if !grouped {
+		for i := range results {
+			rows = append(rows, listRow{Text: displayTitle(results[i]), Option: &results[i]})
+		}
+		return rows
+	}
+
+	var paths []string
+	groups := make(map[string][]int)
+	for i, opt := range results {
+		if _, seen := groups[opt.Path]; !seen {
+			paths = append(paths, opt.Path)
+		}
+		groups[opt.Path] = append(groups[opt.Path], i)
+	}
+
+	for _, path := range paths {
+		header := path
+		if header == "" {
+			header = rootTitle
+		}
+		rows = append(rows, listRow{Text: header})
+		for _, i := range groups[path] {
+			rows = append(rows, listRow{Text: displayTitle(results[i]), Option: &results[i]})
+		}
+	}
+	return rows
+}
+
+// Rows of the info box below the list
+const defaultInfoBoxHeight = 5
+
+// infoBoxHeight sizes the info box for a terminal of the given height, on
+// short terminals most rows go to the list
+func infoBoxHeight(screenHeight int) int {
+	// Borders take 3 rows, the list keeps at least twice the info box
+	return max(1, min(defaultInfoBoxHeight, (screenHeight-3)/3))
+}
+
+// layoutRows are the grid's row heights: the input row when there is one, the
+// list taking what's left, then the info box, a single line when compact
+func layoutRows(withInput bool, infoHeight int, compact bool) []int {
+	if compact {
+		infoHeight = 1
+	}
+	if withInput {
+		return []int{1, 0, infoHeight}
+	}
+	return []int{0, infoHeight}
+}
+
+// singleResult is the search result --select-single picks right away, there
+// has to be exactly one
+func singleResult(results []Option, selectSingle bool) (Option, bool) {
+	if !selectSingle || len(results) != 1 {
+		return Option{}, false
+	}
+	return results[0], true
+}
+
+// phraseForm asks for phrase before a command runs, its Run button stays
+// disabled until the phrase is typed exactly. Cancel and Escape call done with
+// false.
+func phraseForm(phrase string, msg messages, done func(confirmed bool)) *tview.Form {
+	form := tview.NewForm()
+	form.AddInputField(msg.get("typePhrase", tview.Escape(phrase)), "", 0, nil, func(typed string) {
+		form.GetButton(0).SetDisabled(typed != phrase)
+	})
+	form.AddButton(msg.get("run"), func() { done(true) }).
+		AddButton(msg.get("cancel"), func() { done(false) }).
+		SetButtonsAlign(tview.AlignCenter).
+		SetCancelFunc(func() { done(false) })
+	form.GetButton(0).SetDisabled(true)
+	return form
+}
+
+// beforeDraw decides what happens before a frame is drawn: the layout is
+// fitted again when the terminal's height changed, and the screen is cleared
+// while the terminal's background is respected. Without the clear the panes
+// are painted solid, so nothing of an earlier frame is left behind.
+func beforeDraw(height int, lastHeight int, respectBackground bool) (relayout bool, clear bool) {
+	return height != lastHeight, respectBackground
+}
+
+// compactDetails is the single line the compact info box shows for an option,
+// its command as it will run or else the first line of its details
+func compactDetails(option Option, details string, command string) string {
+	if option.Command != "" {
+		return "[gray]$[-] " + tview.Escape(command)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(details), "\n")
+	return line
+}
+
+// selectableRow finds the nearest selectable row starting at index and moving by
+// step, then in the opposite direction, returns -1 if no row can be selected
+func selectableRow(rows []listRow, index int, step int) int {
+	for _, s := range []int{step, -step} {
+		for i := index; i >= 0 && i < len(rows); i += s {
+			if rows[i].Selectable() {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// displayTitle is the list label for an option, with a > prefix for items with
+// children and dimmed when disabled or there's nothing to run
+func displayTitle(option Option) string {
+	title := option.Title
+	if option.Separator {
+		return "[gray::b]" + title + "[-::-]"
+	}
+	if len(option.Children) > 0 || option.ChildrenCmd != "" || isEmptyCategory(option) {
+		title = "> " + option.Title
+	}
+	if option.Disabled || isEmptyCategory(option) || isMissingCommand(option) {
+		title = "[gray]" + title + "[-]"
+	}
+	return title
+}
+
+// Longest command shown under a title with --show-commands, in runes
+const commandPreviewLength = 60
+
+// secondaryText is the gray line under an option in the list, its subtitle, or
+// with showCommands the expanded command of a leaf and the size of a category
+func secondaryText(option Option, showCommands bool, expand func(string) string) string {
+	if !showCommands {
+		return option.Subtitle
+	}
+	if len(option.Children) > 0 {
+		if len(option.Children) == 1 {
+			return "1 item"
+		}
+		return fmt.Sprintf("%d items", len(option.Children))
+	}
+	if option.Command == "" {
+		return option.Subtitle
+	}
+
+	command := []rune(expand(option.Command))
+	if len(command) > commandPreviewLength {
+		command = append(command[:commandPreviewLength-1], '…')
+	}
+	return tview.Escape(string(command))
 }
 
 // expands ~/ to the user's home directory
@@ -120,7 +723,7 @@ func expandCommand(command string) string {
 		return command
 	}
 	
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := findHomeDir()
 	if err != nil {
 		return command // Return original command if home dir can't be determined
 	}
@@ -128,45 +731,517 @@ func expandCommand(command string) string {
 	return strings.ReplaceAll(command, "~/", filepath.Join(homeDir, "")+"/")
 }
 
-// execute command and stop the app
-func executeCommand(option Option, app *tview.Application) {
-	if len(option.Command) == 0 {
+// emitCommand hands the command to the shell wrapper on stdout, or writes it to outPath when set
+func emitCommand(command string, outPath string) error {
+	if outPath == "" {
+		fmt.Print(command)
+		return nil
+	}
+	if err := os.WriteFile(outPath, []byte(command), 0600); err != nil {
+		return fmt.Errorf("failed to write command to %s: %v", outPath, err)
+	}
+	return nil
+}
+
+// finishMenu hands the printed command to emit once the menu has closed and
+// returns the exit status to leave with. Quitting without a selection emits
+// nothing and returns abortExitCode, so the wrapper never evals a stray line.
+func finishMenu(selected bool, command string, exitCode int, emit func(string) error) (int, error) {
+	if !selected {
+		return abortExitCode, nil
+	}
+	if command != "" {
+		// Empty when the command ran in exec mode instead
+		if err := emit(command); err != nil {
+			return 1, err
+		}
+	}
+	return exitCode, nil
+}
+
+// diagnostics receives warnings and other non-fatal messages, --quiet discards them
+var diagnostics io.Writer = os.Stderr
+
+// warn prints a non-fatal warning to diagnostics
+func warn(format string, args ...any) {
+	fmt.Fprintf(diagnostics, "Warning: "+format+"\n", args...)
+}
+
+// pathOutput is where --print-path writes to, stdout and stderr are used as is
+// so the file is never closed behind their back
+func pathOutput(fd int) *os.File {
+	switch fd {
+	case 1:
+		return os.Stdout
+	case 2:
+		return os.Stderr
+	}
+	return os.NewFile(uintptr(fd), "path output")
+}
+
+// emitPath writes the breadcrumb path of the selected option for --print-path
+func emitPath(out *os.File, option Option) {
+	if out == nil {
 		return
 	}
-	
-	expandedCommand := expandCommand(option.Command)
-	fmt.Print(expandedCommand)
-	app.Stop()
+	if _, err := fmt.Fprintln(out, joinPath(option.Path, option.Title)); err != nil {
+		warn("failed to write path: %v", err)
+	}
 }
 
+
 func main() {
+	showTreeStats := flag.Bool("stats", false, "print option counts and tree depth for the config and exit")
+	formatFile := flag.Bool("fmt", false, "print the config, or the file given after the flags, as canonically formatted JSON and exit")
+	writeFormatted := flag.Bool("write", false, "with --fmt, write the formatted config back to the file instead of printing it")
+	checkConfig := flag.Bool("check", false, "report leaves without a command and duplicate titles, exit with status 1 if there are any")
+	exportMD := flag.Bool("export-md", false, "print the menu as a Markdown document and exit")
+	initialSearch := flag.String("search", "", "open the menu searching for this query")
+	flag.StringVar(initialSearch, "s", "", "shorthand for --search")
+	selectSingle := flag.Bool("select-single", false, "with --search, pick the result right away when there's exactly one")
+	selectPath := flag.String("select", "", "print or run the option at this path, e.g. \"Docker/Docker Down\", without opening the menu")
+	assumeYes := flag.Bool("yes", false, "with --select or --first, run or print options that ask for confirmation without asking")
+	firstQuery := flag.String("first", "", "print or run the best search match for this query, like --select, without opening the menu")
+	listPaths := flag.Bool("list-paths", false, "print the path of every option --select can pick and exit")
+	completionShell := flag.String("completion", "", "print a completion script for bash or zsh and exit")
+	minScore := flag.Int("min-score", defaultMinScore, "minimum fuzzy match score (0-100) for search results")
+	searchDetails := flag.Bool("search-details", false, "also match search terms against option details")
+	groupResults := flag.Bool("group-results", false, "group search results under their parent menu path")
+	execMode := flag.Bool("exec", false, "run the selected command directly instead of printing it for the shell wrapper")
+	quiet := flag.Bool("quiet", false, "don't print warnings, only the selected command and fatal errors")
+	twoStep := flag.Bool("two-step", false, "show the full command on the first Enter and only run it on a second one")
+	loopMode := flag.Bool("loop", false, "with --exec, come back to the menu after each command instead of exiting")
+	outputLines := flag.Int("output-lines", 0, "show this many of the last lines a command printed in the info box when --exec comes back to the menu, its output is piped through talias then")
+	notifyAll := flag.Bool("notify", false, "send a desktop notification when a command run with --exec finishes")
+	configURL := flag.String("url", "", "load the config from an http(s) URL, overrides TALIAS_CONFIG")
+	fetchTimeout := flag.Duration("url-timeout", defaultFetchTimeout, "timeout for fetching a config URL")
+	maxConfigSize := flag.Int64("url-max-size", defaultMaxConfigSize, "maximum size in bytes of a fetched config")
+	cacheTTL := flag.Duration("cache-ttl", defaultCacheTTL, "reuse a fetched config URL for this long before fetching it again")
+	refreshConfig := flag.Bool("refresh", false, "fetch the config URL even if the cached copy is still fresh")
+	configChecksum := flag.String("config-sha256", "", "refuse to load the config unless its SHA-256 checksum matches this hex digest")
+	redact := flag.String("redact", "", "regular expression for commands that are never recorded in stats.json")
+	envOverride := flag.Bool("env-override", false, "let ~/.talias/env override variables that are already set")
+	renameDuplicates := flag.Bool("rename-duplicates", false, "number options whose title repeats a sibling's, e.g. \"Title (2)\", instead of warning")
+	configShell := flag.String("shell", "", "shell to run commands with, defaults to $SHELL or sh")
+	outPath := flag.String("out", "", "write the selected command to this file instead of stdout")
+	printPath := flag.Bool("print-path", false, "also write the path of the selected option, e.g. \"Git/Branch/delete\", to stderr")
+	pathFD := flag.Int("path-fd", 2, "file descriptor --print-path writes to")
+	var tags tagFlags
+	flag.Var(&tags, "tag", "only show options with this tag and the categories leading to them, can be repeated")
+	tagMode := flag.String("tag-mode", "any", "with several --tag flags, show options having \"any\" or \"all\" of them")
+	pruneEmpty := flag.Bool("prune-empty", false, "hide categories with nothing to run below them, instead of showing them dimmed")
+	recursiveCounts := flag.Bool("recursive-counts", false, "count all options below a category in the list instead of its direct children")
+	showLetters := flag.Bool("letter-index", false, "show the first letters of the current menu's titles left of the list, Alt+letter jumps to them")
+	showCommands := flag.Bool("show-commands", false, "show each command under its title in the list, and the number of items under categories")
+	detailsMaxBytes := flag.Int("details-max-bytes", defaultDetailsMaxBytes, "truncate details shown while moving through the list to this many bytes, 0 for no limit")
+	detailsMaxLines := flag.Int("details-max-lines", defaultDetailsMaxLines, "truncate details shown while moving through the list to this many lines, 0 for no limit")
+	markdownDetails := flag.Bool("markdown", false, "render all details as markdown")
+	templatedDetails := flag.Bool("template-details", false, "render all details as Go templates of the option's fields, e.g. {{.Command}}")
+	maxResults := flag.Int("max-results", defaultMaxResults, "maximum number of search results to show, 0 for no limit")
+	dedupeResults := flag.Bool("dedupe-results", false, "show options with the same title and command only once in search results")
+	useIndexCache := flag.Bool("index-cache", false, "keep the search index in ~/.talias/index.cache between runs")
+	mostUsedCount := flag.Int("most-used", 5, "number of commands in the \"Most used\" category, 0 to hide it")
+	flag.Parse()
+	if *quiet {
+		diagnostics = io.Discard
+	}
+
+	var pathOut *os.File
+	if *printPath {
+		pathOut = pathOutput(*pathFD)
+	}
+
 	app := tview.NewApplication()
 
 	// Load options from file in ~/.talias directory
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := findHomeDir()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 	
+	// Variables from ~/.talias/env, loaded first so they apply to everything below
+	envVars, err := loadEnvFile(filepath.Join(homeDir, ".talias", "env"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading env file: %v\n", err)
+		os.Exit(1)
+	}
+	envFileNames := applyEnv(envVars, *envOverride)
+
+	// UI strings in the language from TALIAS_LANG or LANG, which may come from the env file
+	msg, err := loadMessages(filepath.Join(homeDir, ".talias", "lang"), languageCode(os.Getenv("TALIAS_LANG"), os.Getenv("LANG")))
+	if err != nil {
+		warn("%v", err)
+		msg = messages{}
+	}
+
+	// TALIAS_CONFIG or --url may point at another file or an http(s) URL
 	configPath := filepath.Join(homeDir, ".talias", "options.json")
-	rootOptions, err := loadOptionsFromFile(configPath)
+	if env := os.Getenv("TALIAS_CONFIG"); env != "" {
+		configPath = env
+	}
+	if *configURL != "" {
+		configPath = *configURL
+	}
+
+	// --fmt formats the config, or the file given after it, and exits
+	if *formatFile {
+		if flag.NArg() > 0 {
+			configPath = flag.Arg(0)
+		}
+		if err := runFormat(configPath, *writeFormatted, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var config Config
+	if isRemoteConfig(configPath) {
+		client := &http.Client{Timeout: *fetchTimeout}
+		fetch := func(url string) ([]byte, error) {
+			return fetchConfig(client, url, *maxConfigSize)
+		}
+		cachePath := remoteCachePath(filepath.Join(homeDir, ".talias", "cache"), configPath)
+		var warning error
+		config, warning, err = loadConfigFromURL(fetch, configPath, cachePath, *configChecksum, *cacheTTL, *refreshConfig, time.Now())
+		if warning != nil {
+			warn("%v", warning)
+		}
+	} else {
+		// A sidecar options.json.sha256 is checked unless a checksum was passed
+		checksum := *configChecksum
+		if checksum == "" {
+			checksum, err = sidecarChecksum(configPath)
+		}
+		if err == nil {
+			config, err = loadConfigFromFile(configPath, checksum)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
 		os.Exit(1)
 	}
+	// Options remember where they came from for the debug view
+	configOptions := withSource(config.Options, configPath)
+
+	// Vars from the config work like the env file, which wins over them
+	for name := range applyEnv(config.envVars(), false) {
+		envFileNames[name] = true
+	}
+	// The config can brand the welcome and empty menu texts
+	for key, text := range config.messages() {
+		msg[key] = text
+	}
+	keys, err := resolveKeys(config.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Commands run with --exec are killed after the config's execTimeout unless
+	// their option sets its own timeoutSeconds
+	execTimeout, err := config.execTimeout()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := checkCategoryOrder(config.CategoryOrder); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkCategoryEnter(config.CategoryEnter); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Colors can be tried out through TALIAS_* variables, the config's theme wins over them
+	colors, colorErrs := themeFromEnv(defaultTheme, os.Getenv)
+	for _, err := range colorErrs {
+		warn("%v", err)
+	}
+	colors, err = config.Theme.apply(colors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading options: %v\n", err)
+		os.Exit(1)
+	}
+	respectBackground := config.respectsBackground()
+	if !respectBackground {
+		colors = colors.withSolidBackground()
+	}
+
+	// Options can inherit fields from named templates, the config's own and any
+	// in templates.json next to it
+	templatesPath := filepath.Join(homeDir, ".talias", "templates.json")
+	templates, err := loadTemplates(templatesPath)
+	if err == nil {
+		configOptions, err = applyTemplates(configOptions, combineTemplates(config.Templates, templates))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading templates: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Scripts in ~/.talias/plugins add a top-level category each, merged in the
+	// order the config asks for
+	scripts, err := pluginScripts(filepath.Join(homeDir, ".talias", "plugins"))
+	if err == nil {
+		scripts, err = orderPlugins(scripts, config.Merge, config.Order, fileModTime)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading plugins: %v\n", err)
+		os.Exit(1)
+	}
+	loadPluginOptions := func() ([]Option, []error) {
+		return loadPlugins(runPlugin, scripts, filepath.Join(homeDir, ".talias", "cache", "plugins"), defaultPluginTimeout, time.Now())
+	}
+
+	// The menu opens without waiting for plugins, they're added once they have
+	// loaded in the background. Anything printing instead of opening the menu
+	// waits for them.
+	interactive := !*showTreeStats && !*checkConfig && !*listPaths && *completionShell == "" && !*exportMD && *selectPath == "" && *firstQuery == ""
+	// --select-single has to see every result before deciding
+	loadingPlugins := interactive && len(scripts) > 0 && !(*selectSingle && *initialSearch != "")
+	var pluginOptions []Option
+	if !loadingPlugins {
+		var pluginErrs []error
+		pluginOptions, pluginErrs = loadPluginOptions()
+		for _, err := range pluginErrs {
+			warn("%v", err)
+		}
+	}
+
+	// The options shown are the config's merged with the plugins', prepared
+	// again when plugins finish loading in the background
+	baseOptions := configOptions
+	var conditions string // Which when conditions held, for the index cache key
+	matchAllTags, err := parseTagMode(*tagMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	prepareOptions := func(pluginOptions []Option) []Option {
+		options := mergeOptions(baseOptions, pluginOptions)
+		// Options can depend on variables like feature flags, from the environment,
+		// the env file or the config's vars
+		conditions = conditionStates(options, os.LookupEnv)
+		options = filterByCondition(options, os.LookupEnv)
+		if len(tags) > 0 {
+			options = filterByTags(options, tags, matchAllTags)
+		}
+		if *pruneEmpty {
+			options = pruneEmptyCategories(options)
+		}
+		// Duplicate sibling titles make navigation ambiguous
+		if *renameDuplicates {
+			options = renameDuplicateTitles(options)
+		}
+		return options
+	}
+	configOptions = prepareOptions(pluginOptions)
+
+	if *showTreeStats {
+		printTreeStats(os.Stdout, computeTreeStats(configOptions))
+		return
+	}
+	if *checkConfig {
+		if !printCheck(os.Stdout, checkOptions(configOptions, ""), optionNotes(configOptions, "")) {
+			os.Exit(1)
+		}
+		return
+	}
+	if *listPaths {
+		for _, path := range optionPaths(configOptions) {
+			fmt.Println(path)
+		}
+		return
+	}
+	if *completionShell != "" {
+		var flags []string
+		flag.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, f.Name)
+		})
+		script, err := completionScript(*completionShell, flags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(script)
+		return
+	}
+	if *exportMD {
+		exportMarkdown(os.Stdout, configOptions)
+		return
+	}
+
+	for _, path := range duplicateTitles(configOptions, "") {
+		warn("duplicate title %q, use --rename-duplicates to number them", path)
+	}
+
+	// Chords are looked up in the whole tree, so they work from any menu
+	chordList, chordErrs := collectChords(configOptions, keys)
+	for _, err := range chordErrs {
+		warn("%v", err)
+	}
+	chords := &chordMatcher{Chords: chordList, Timeout: chordTimeout}
+
+	// Commands matching the config's confirmPattern are confirmed like options marked confirm
+	var confirmPattern *regexp.Regexp
+	if config.ConfirmPattern != "" {
+		confirmPattern, err = regexp.Compile(config.ConfirmPattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading options: invalid confirmPattern: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var redactPattern *regexp.Regexp
+	if *redact != "" {
+		redactPattern, err = regexp.Compile(*redact)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing --redact pattern: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Execution counts drive the "Most used" category
+	statsPath := filepath.Join(homeDir, ".talias", "stats.json")
+	stats, err := loadStats(statsPath)
+	if err != nil {
+		warn("stats not loaded, %v", err)
+		stats = make(map[string]CommandStats)
+	}
+	var statsErr error // Reported once the UI has closed
+	var hookErr error  // Reported once the UI has closed
+
+	// The config's onSelect hook is told about every option that runs
+	runHook := func(option Option) error {
+		if config.OnSelect == "" {
+			return nil
+		}
+		return startHook(config.OnSelect, hookEnv(os.Environ(), option, joinPath(option.Path, option.Title)))
+	}
+
+	// Argument values entered last prefill the next argument form
+	lastArgsPath := filepath.Join(homeDir, ".talias", "args.json")
+	argValues, err := loadLastArgs(lastArgsPath)
+	if err != nil {
+		warn("last arguments not loaded, %v", err)
+		argValues = make(lastArgs)
+	}
+	var argValuesErr error // Reported once the UI has closed
+
+	// The main menu, ending in a row that says so while plugins are loading
+	rootMenu := func() []Option {
+		return withLoadingRow(withMostUsed(withTags(configOptions), stats, *mostUsedCount), loadingPlugins, msg.get("loading", "plugins"))
+	}
+	rootOptions := rootMenu()
+
+	// --select runs or prints an option by its path without opening the menu,
+	// --first the best match for a search
+	if *selectPath != "" || *firstQuery != "" {
+		var option Option
+		var found bool
+		name := *selectPath
+		if *firstQuery != "" {
+			option, found = firstMatch(configOptions, *firstQuery, searchSettings{MinScore: *minScore, MatchDetails: *searchDetails})
+			name = joinPath(option.Path, option.Title)
+		} else {
+			option, found = findOptionByPath(configOptions, *selectPath)
+		}
+		command := expandCommand(expandEnvVars(option.Command, envFileNames))
+		switch {
+		case !found && *firstQuery != "":
+			err = fmt.Errorf("nothing matches %q", *firstQuery)
+		case !found:
+			err = fmt.Errorf("no option at %q, see --list-paths", *selectPath)
+		default:
+			err = checkSelected(option, name, command, confirmPattern, *assumeYes)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if option.EditFile != "" {
+			if err := runEditor(editorCommand(os.Getenv("EDITOR"), editFilePath(option.EditFile))); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := runHook(option); err != nil {
+			warn("%v", err)
+		}
+		if shouldRecord(option, command, redactPattern) {
+			recordExecution(stats, option, time.Now())
+			if err := saveStats(statsPath, stats); err != nil {
+				warn("stats not saved, %v", err)
+			}
+		}
+		emitPath(pathOut, option)
+		if *execMode {
+			code := runSelected(option, command, *configShell, *notifyAll, execTimeout, nil)
+			printPostMessage(os.Stderr, option, code)
+			os.Exit(code)
+		}
+		if err := emitCommand(printedCommand(option, command, *configShell), *outPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(option.ExitCode)
+	}
 
 	// Navigation state
 	var currentOptions []Option = rootOptions
 	var menuStack [][]Option
-	var currentTitle string = "Main Menu"
+	var currentTitle string = msg.get("mainMenu")
+	var currentPath string // Breadcrumb of the current menu, empty at the top
 	
 	// Search state
 	var searchMode bool = false
 	var searchQuery string = ""
+
+	// Filter state, narrowing the current menu without leaving it
+	var filterMode bool
+	var menuFilter string
 	var searchResults []Option
-	var allOptions []Option = flattenOptions(rootOptions) // Flattened list of all options for search
+	var searchRows []listRow // Rows shown for searchResults, including any group headers
+	var menuRows []listRow   // Rows shown for currentOptions
+	var previousIndex int    // Last selected list index, used to skip rows in the direction of travel
+	var allOptions *optionIndex // Flattened list of all options, prepared for search
+
+	// The index can be kept in ~/.talias/index.cache between runs, remote configs
+	// have no modification time to key it on
+	buildIndex := func(pluginOptions []Option) (*optionIndex, error) {
+		if !*useIndexCache || isRemoteConfig(configPath) {
+			return newOptionIndex(flattenOptions(configOptions)), nil
+		}
+		indexPath := filepath.Join(homeDir, ".talias", "index.cache")
+		// Plugin output has no file to stat, so it's part of the key itself
+		pluginData, _ := json.Marshal(pluginOptions)
+		key := indexKey([]string{configPath, templatesPath}, fmt.Sprintf("rename-duplicates=%t prune-empty=%t when=%s tags=%s all-tags=%t plugins=%s", *renameDuplicates, *pruneEmpty, conditions, tags.String(), matchAllTags, pluginData))
+		if index, found := loadIndex(indexPath, key); found {
+			return index, nil
+		}
+		index := newOptionIndex(flattenOptions(configOptions))
+		return index, saveIndex(indexPath, key, index)
+	}
+	if loadingPlugins {
+		// Indexed once the plugins are in
+		allOptions = newOptionIndex(flattenOptions(configOptions))
+	} else {
+		index, err := buildIndex(pluginOptions)
+		if err != nil {
+			warn("%v", err)
+		}
+		allOptions = index
+	}
 	
+	// Argument form state, the prompts' state is reset along with it
+	var showArgumentForm func(Option, []Parameter)
+
 	// Parameter prompt state
 	var currentParameterOption Option
 	var currentParameters []Parameter
@@ -176,28 +1251,83 @@ func main() {
 	
 	// Grid reference (will be initialized later)
 	var grid *tview.Grid
+	var pages *tview.Pages // grid with dialogs like the confirmation on top
 
 	// Function declarations for parameter prompts
 	var showParameterPrompts func(Option, []Parameter)
 	var showNextParameterPrompt func()
 	var handleCommand func(Option)
 	var executeCommandWithParameters func(Option, []Parameter, map[string]string)
+	var executeCommand func(Option, string)
+	var recordUsage func(Option, string)
+	var showDetails func(Option)
+	var switchToMainMenu func()
+
+	// With --two-step the first Enter only shows the command
+	var pendingRun twoStepRun
+
+	// Commands marked with Space, Enter runs all of them once it's confirmed
+	var marked batch
+	var runMarked func()
+
+	// Cancelled once the UI has closed, so background work stops queueing updates
+	appCtx, cancelApp := context.WithCancel(context.Background())
+	// Stops refreshing a generated menu, replaced while one is open
+	stopRefresh := func() {}
+
+	// Last executed option, for re-running with '.'
+	var last lastRun
+	if opt, found := lastUsedOption(stats, configOptions); found {
+		last.Record(opt, opt.Command)
+	}
+
+	// Command to run once the UI has closed in exec mode
+	var execOption *Option
+	var execCommand string
+
+	// Whether a command was chosen, quitting any other way is an abort, the
+	// command to print for the wrapper and the exit status its option asks for
+	var selected bool
+	var printCommand string
+	var selectedExitCode int
+	var chosenOption Option
+	var chosenBatch batch // Marked commands chosen together, printed or run in exec mode
 
 	// Top: list
 	list := tview.NewList()
-	list.SetBackgroundColor(tcell.ColorDefault)
+	list.SetBackgroundColor(colors.Background)
+	list.SetSecondaryTextColor(tcell.ColorGray)
+	list.SetSelectedBackgroundColor(colors.Selected)
+	if colors.Selection == selectionArrow {
+		// Only the marker shows the selection
+		list.SetSelectedTextColor(tview.Styles.PrimaryTextColor)
+		list.SetSelectedBackgroundColor(colors.Background)
+	}
+
+	items := newMarkedList(list, colors.Selection)
+
+	// Optional jump list of the current menu's first letters left of the list
+	letterBar := tview.NewTextView().SetDynamicColors(true)
+	letterBar.SetBackgroundColor(colors.Background)
+	var listPane tview.Primitive = list
+	if *showLetters {
+		listPane = tview.NewFlex().
+			AddItem(letterBar, 2, 0, false).
+			AddItem(list, 0, 1, true)
+	}
+	var letters []letterEntry
 
 	// Bottom: info box
 	infoBox := tview.NewTextView().
-		SetText("Welcome! Select an option.").
+		SetText(msg.get("welcome")).
 		SetDynamicColors(true).
 		SetWrap(true)
-	infoBox.SetBackgroundColor(tcell.ColorDefault)
+	infoBox.SetBackgroundColor(colors.Background)
 
 	// Search input field
 	searchInput := tview.NewInputField().
-		SetLabel("Search: ")
-	searchInput.SetBackgroundColor(tcell.ColorDefault)
+		SetLabel(msg.get("searchLabel"))
+	searchInput.SetBackgroundColor(colors.Background)
 	
 	// Custom input capture for search input to handle up/down navigation
 	searchInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -205,23 +1335,23 @@ func main() {
 		if event.Key() == tcell.KeyUp {
 			currentIndex := list.GetCurrentItem()
 			if currentIndex > 0 {
-				list.SetCurrentItem(currentIndex - 1)
+				list.SetCurrentItem(selectableRow(searchRows, currentIndex-1, -1))
 			}
 			return nil
 		}
 		if event.Key() == tcell.KeyDown {
 			currentIndex := list.GetCurrentItem()
-			if currentIndex < len(searchResults)-1 {
-				list.SetCurrentItem(currentIndex + 1)
+			if currentIndex < len(searchRows)-1 {
+				list.SetCurrentItem(selectableRow(searchRows, currentIndex+1, 1))
 			}
 			return nil
 		}
 		// Handle Enter to execute selected command
 		if event.Key() == tcell.KeyEnter {
-			if len(searchResults) > 0 && list.GetCurrentItem() >= 0 {
+			if len(searchRows) > 0 && list.GetCurrentItem() >= 0 {
 				selectedIndex := list.GetCurrentItem()
-				if selectedIndex < len(searchResults) {
-					handleCommand(searchResults[selectedIndex])
+				if selectedIndex < len(searchRows) && searchRows[selectedIndex].Option != nil {
+					handleCommand(*searchRows[selectedIndex].Option)
 				}
 			}
 			return nil
@@ -230,80 +1360,584 @@ func main() {
 		return event
 	})
 
+	// Back/forward history of visited menus
+	var history navHistory
+	// Remember the selection of the menu being left
+	leaveMenu := func() {
+		history.SetSelected(list.GetCurrentItem())
+		stopRefresh()
+		stopRefresh = func() {}
+	}
+	// Record the menu just navigated to
+	enterMenu := func() {
+		history.Push(menuVisit{
+			Options: currentOptions,
+			Stack:   append([][]Option(nil), menuStack...),
+			Title:   currentTitle,
+			Path:    currentPath,
+		})
+	}
+	enterMenu()
+
+	// Info box text for the menu just shown, a menu without options says so
+	menuHint := func() string {
+		if len(currentOptions) == 0 {
+			return msg.get("emptyCategory")
+		}
+		return msg.get("selectFrom", currentTitle)
+	}
+
+	// Commands as they'll be run, for --show-commands
+	previewCommand := func(command string) string {
+		return expandCommand(expandEnvVars(command, envFileNames))
+	}
+
+	// Hotkeys of the options in the current menu, pressing one activates its option
+	var hotkeys map[rune]int
+	normalListCapture := hotkeyCapture(list, func() map[rune]int { return hotkeys })
+	list.SetInputCapture(normalListCapture)
+
 	// Function to populate list with current options
 	var populateList func()
+	var openGeneratedMenu func(Option)
+	var openCategory func(Option)
+	var previewCategory func(Option)
+	detailsCmds := newDetailsCmdRunner(shellOutput)
+	substituter := newDetailsSubstituter(shellOutput, defaultSubstitutionTimeout)
+	substitutions := newDetailsCmdRunner(func(ctx context.Context, details string) (string, error) {
+		return substituter.Expand(ctx, details), nil
+	})
+	var loadingDetails *spinner
+	// cancelDetails drops whatever is still loading for the details shown before
+	cancelDetails := func() {
+		detailsCmds.Cancel()
+		substitutions.Cancel()
+		if loadingDetails != nil {
+			loadingDetails.Stop()
+			loadingDetails = nil
+		}
+	}
 	populateList = func() {
-		list.Clear()
-		for _, o := range currentOptions {
-			option := o // capture
-			
-			// Add > prefix for items with children
-			displayTitle := option.Title
-			if len(option.Children) > 0 {
-				displayTitle = "> " + option.Title
+		cancelDetails()
+		items.Clear()
+		visibleOptions := currentOptions
+		if menuFilter != "" {
+			visibleOptions = filterOptions(currentOptions, menuFilter, *minScore)
+		}
+		visibleOptions = groupByKind(visibleOptions, config.CategoryOrder)
+		menuRows = buildMenuRows(visibleOptions, *recursiveCounts)
+		letters = letterIndex(visibleOptions)
+		letterBar.SetText(renderLetters(letters, 0))
+		for i, row := range menuRows {
+			if !row.Skip {
+				menuRows[i].Text = colors.levelText(*row.Option, row.Text)
 			}
-			list.AddItem(displayTitle, "", 0, func() {
-
-				// Check if this option has children
-				if len(option.Children) > 0 {
+		}
+		hotkeys = menuHotkeys(visibleOptions, keys)
+		for key, i := range hotkeys {
+			menuRows[i].Text = "[gray]" + tview.Escape("["+string(key)+"]") + "[-] " + menuRows[i].Text
+		}
+		for i, row := range menuRows {
+			option := *row.Option
+			if option.Path == "" {
+				option.Path = currentPath
+			}
+			if !row.Skip && marked.Has(option) {
+				menuRows[i].Text = "[yellow::b]*[-::-] " + row.Text
+			}
+		}
+		for _, row := range menuRows {
+			option := *row.Option // capture
+			if option.Disabled || isEmptyCategory(option) || option.Separator {
+				// Shown for documentation, Enter does nothing
+				items.Add(row.Text, secondaryText(option, *showCommands, previewCommand), nil)
+				continue
+			}
+			
+			items.Add(row.Text, secondaryText(option, *showCommands, previewCommand), func() {
 
-					// Navigate to child menu
-					menuStack = append(menuStack, currentOptions)
-					currentOptions = option.Children
-					currentTitle = option.Title
-					populateList()
-					infoBox.SetText("Select an option from " + currentTitle)
+				if opensOnEnter(option) {
+					if categoryKeyOpens(config.CategoryEnter, tcell.KeyEnter) {
+						openCategory(option)
+					} else {
+						previewCategory(option)
+					}
 				} else {
-					// Execute command
+					// Execute command, options from "Most used" know their own path
+					if option.Path == "" {
+						option.Path = currentPath
+					}
+					// Marked commands run instead of the selected one
+					if len(marked) > 0 {
+						runMarked()
+						return
+					}
 					handleCommand(option)
 				}
 			})
 		}
+		if first := selectableRow(menuRows, 0, 1); first > 0 {
+			list.SetCurrentItem(first)
+		}
+	}
+
+	// Preselect the child a category names as its default
+	selectDefaultChild := func(category Option) {
+		if category.DefaultChild == "" {
+			return
+		}
+		if index, ok := defaultChildIndex(menuRows, category.DefaultChild); ok {
+			list.SetCurrentItem(index)
+		}
+	}
+
+	// Navigate into a category, a filtered one opens unfiltered
+	openCategory = func(option Option) {
+		if len(option.Children) == 0 && option.ChildrenCmd == "" {
+			return
+		}
+		if filterMode {
+			switchToMainMenu()
+		}
+		if len(option.Children) == 0 {
+			openGeneratedMenu(option)
+			return
+		}
+
+		leaveMenu()
+		menuStack = append(menuStack, currentOptions)
+		currentOptions = option.Children
+		currentTitle = option.Title
+		currentPath = joinPath(currentPath, option.Title)
+		enterMenu()
+		populateList()
+		selectDefaultChild(option)
+		infoBox.SetText(menuHint())
+	}
+
+	// List a category's children in the info box without opening it
+	previewCategory = func(option Option) {
+		hint := "[gray]" + tview.Escape(msg.get("openRight", option.Title)) + "[-]"
+		if children := previewChildren(option); children != "" {
+			hint = children + "\n\n" + hint
+		}
+		infoBox.SetText(hint)
+	}
+
+	// Open a menu whose children come from its childrenCmd, loading in the
+	// background so the spinner keeps moving
+	loadingMenu := false
+	openGeneratedMenu = func(option Option) {
+		if loadingMenu {
+			return
+		}
+		loadingMenu = true
+		loading := startSpinner(appCtx, app, infoBox, msg.get("loading", option.Title))
+		go func() {
+			children, err := loadChildren(appCtx, shellOutput, option.ChildrenCmd)
+			app.QueueUpdateDraw(func() {
+				loading.Stop()
+				loadingMenu = false
+				if err != nil {
+					infoBox.SetText(fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error())))
+					return
+				}
+
+				leaveMenu()
+				menuStack = append(menuStack, currentOptions)
+				currentOptions = children
+				currentTitle = option.Title
+				currentPath = joinPath(currentPath, option.Title)
+				enterMenu()
+				populateList()
+				selectDefaultChild(option)
+				infoBox.SetText(menuHint())
+				if option.RefreshSeconds <= 0 {
+					return
+				}
+
+				// Keep the menu current until it's left
+				stopRefresh = refreshEvery(appCtx, time.Duration(option.RefreshSeconds)*time.Second, func(ctx context.Context) {
+					children, err := loadChildren(ctx, shellOutput, option.ChildrenCmd)
+					app.QueueUpdateDraw(func() {
+						if ctx.Err() != nil {
+							// The menu was left while the command ran
+							return
+						}
+						if err != nil {
+							infoBox.SetText(fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error())))
+							return
+						}
+						if searchMode || parameterMode {
+							// Shown when the menu is back on screen
+							currentOptions = children
+							return
+						}
+						previous, index := menuRows, list.GetCurrentItem()
+						currentOptions = children
+						populateList()
+						list.SetCurrentItem(preserveSelection(previous, index, menuRows))
+					})
+				})
+			})
+		}()
 	}
 
 	// Function to populate search results
 	var populateSearchResults func()
 	populateSearchResults = func() {
-		list.Clear()
-		searchResults = fuzzySearch(searchQuery, allOptions)
-		for _, option := range searchResults {
-			opt := option // capture
-			displayTitle := opt.Title
-			if len(opt.Children) > 0 {
-				displayTitle = "> " + opt.Title
-			}
-			list.AddItem(displayTitle, "", 0, func() {
+		cancelDetails()
+		items.Clear()
+		letters = nil
+		letterBar.SetText("")
+		searchResults = allOptions.Search(searchQuery, searchSettings{MinScore: *minScore, MatchDetails: *searchDetails})
+		if *dedupeResults {
+			searchResults = dedupeOptions(searchResults)
+		}
+		var hidden int
+		searchResults, hidden = limitResults(searchResults, *maxResults)
+		searchRows = searchResultRows(searchResults, hidden, *groupResults, searchQuery, msg)
+		if len(searchResults) == 0 && searchQuery != "" {
+			infoBox.SetText("[gray]" + msg.get("noMatchesHint") + "[-]")
+		}
+		for _, row := range searchRows {
+			if row.Option == nil {
+				// Group header, "more" or "no matches" row, not selectable
+				items.Add("[gray::b]"+row.Text+"[-::-]", "", nil)
+				continue
+			}
+			opt := *row.Option // capture
+			text := row.Text
+			if !opt.Disabled && !isMissingCommand(opt) {
+				base, _ := colors.levelColor(opt.Level)
+				text = highlightMatch(opt.Title, searchQuery, colors.Highlight, base)
+			}
+			items.Add(text, secondaryText(opt, *showCommands, previewCommand), func() {
 				handleCommand(opt)
 			})
 		}
+		if first := selectableRow(searchRows, 0, 1); first > 0 {
+			list.SetCurrentItem(first)
+		}
+	}
+
+	// Returns the option under the cursor in either normal or search mode
+	selectedOption := func() (Option, bool) {
+		if searchMode {
+			return optionAt(searchRows, list.GetCurrentItem())
+		}
+		return optionAt(menuRows, list.GetCurrentItem())
+	}
+
+	// Show the menu of visit, recording it in the back/forward history
+	goToVisit := func(visit menuVisit) {
+		leaveMenu()
+		currentOptions = visit.Options
+		menuStack = visit.Stack
+		currentTitle = visit.Title
+		currentPath = visit.Path
+		enterMenu()
+		populateList()
+		infoBox.SetText(menuHint())
+	}
+
+	// Jump straight back to the main menu from any depth
+	goToRoot := func() {
+		goToVisit(rootVisit(rootOptions, msg.get("mainMenu")))
+	}
+
+	// Show a menu from the back/forward history as it was left
+	restoreVisit := func(visit menuVisit, ok bool) {
+		if !ok {
+			return
+		}
+		currentOptions = visit.Options
+		menuStack = append([][]Option(nil), visit.Stack...)
+		currentTitle = visit.Title
+		currentPath = visit.Path
+		populateList()
+		list.SetCurrentItem(visit.Selected)
+		infoBox.SetText(menuHint())
+	}
+
+	// Copy the selected option's details to the clipboard
+	copySelectedDetails := func() {
+		option, ok := selectedOption()
+		if !ok || option.Details == "" {
+			infoBox.SetText(msg.get("nothingToCopy"))
+			return
+		}
+		if err := copyToClipboard(option.Details); err != nil {
+			infoBox.SetText("[red]" + msg.get("copyFailed", err) + "[-]")
+			return
+		}
+		infoBox.SetText(msg.get("copied", option.Title))
+	}
+
+	// The debug view shows an option's path, raw command and notes instead of its details
+	debugView := false
+	compactInfo := false // info box shows a single line, see toggleCompactInfo
+	toggleDebugView := func() {
+		debugView = !debugView
+		if option, ok := selectedOption(); ok {
+			showDetails(option)
+		}
+	}
+
+	// Copy the selected option's breadcrumb path, e.g. for telling someone where to find it
+	copySelectedPath := func() {
+		option, ok := selectedOption()
+		if !ok {
+			infoBox.SetText(msg.get("nothingToCopy"))
+			return
+		}
+		// Search results carry their own path, whichever menu the search started in
+		menuPath := currentPath
+		if searchMode {
+			menuPath = ""
+		}
+		path := breadcrumb(option, menuPath)
+		if err := copyToClipboard(path); err != nil {
+			infoBox.SetText("[red]" + msg.get("copyFailed", err) + "[-]")
+			return
+		}
+		infoBox.SetText(msg.get("copiedPath", tview.Escape(path)))
+	}
+
+	// Show an option's details in the info box, running any $(...) commands in them
+	// and rendering markdown if asked to
+	// Untruncated text of the details shown, set while they're truncated
+	var fullDetails string
+
+	// Tab moves focus to the info box to scroll through truncated details, Tab or Escape moves it back
+	focusDetails := func() {
+		if fullDetails == "" {
+			return
+		}
+		infoBox.SetText(fullDetails).ScrollToBeginning()
+		app.SetFocus(infoBox)
+	}
+	unfocusDetails := func() {
+		app.SetFocus(list)
+		if option, ok := selectedOption(); ok {
+			showDetails(option)
+		}
+	}
+
+	var renderDetails func(option Option, details string, extra string)
+	showDetails = func(option Option) {
+		// Whatever was selected before doesn't need its substitutions or
+		// detailsCmd anymore, nor is its two-step run still pending
+		cancelDetails()
+		pendingRun.Cancel()
+		menuPath := currentPath
+		if searchMode {
+			menuPath = ""
+		}
+		if debugView {
+			fullDetails = ""
+			infoBox.SetText("[gray]" + tview.Escape(debugDetails(option, breadcrumb(option, menuPath))) + "[-]")
+			return
+		}
+
+		// The details are shown as written until their $(...) substitutions
+		// are done, the detailsCmd output goes below them
+		details, substituted := option.Details, true
+		if strings.Contains(option.Details, "$(") {
+			var expanded string
+			if expanded, substituted = substitutions.Cached(option.Details); substituted {
+				details = expanded
+			}
+		}
+		extra, extraDone := "", option.DetailsCmd == ""
+		key := breadcrumb(option, menuPath) + "\n" + option.DetailsCmd
+		if !extraDone {
+			var output string
+			if output, extraDone = detailsCmds.Cached(key); extraDone {
+				extra = tview.Escape(strings.TrimSpace(output))
+			}
+		}
+		if substituted && extraDone {
+			renderDetails(option, details, extra)
+			return
+		}
+
+		// Run what's missing in the background with a spinner below the
+		// details until it's all done
+		update := func(f func()) { app.QueueUpdateDraw(f) }
+		ticker := time.NewTicker(spinnerInterval)
+		loading := newSpinner(ticker.C, update, func(frame string) {
+			renderDetails(option, details, strings.TrimSpace(extra+"\n[gray]"+frame+" "+msg.get("loadDetails")+"[-]"))
+		})
+		loading.cleanup = ticker.Stop
+		finish := func() {
+			if !substituted || !extraDone {
+				return
+			}
+			loading.Stop()
+			loadingDetails = nil
+			renderDetails(option, details, extra)
+		}
+		if !substituted {
+			substitutions.Start(appCtx, option.Details, option.Details, substituter.Timeout(option.Details), update, func(output string, err error) {
+				// Only running past the overall timeout fails it, the
+				// details are then shown as written
+				if err == nil {
+					details = output
+				}
+				substituted = true
+				finish()
+			})
+		}
+		if !extraDone {
+			timeout := defaultDetailsCmdTimeout
+			if option.DetailsTimeoutSeconds > 0 {
+				timeout = time.Duration(option.DetailsTimeoutSeconds) * time.Second
+			}
+			detailsCmds.Start(appCtx, key, option.DetailsCmd, timeout, update, func(output string, err error) {
+				switch {
+				case errors.Is(err, errDetailsTimedOut):
+					extra = "[gray]" + tview.Escape(msg.get("timedOut")) + "[-]"
+				case err != nil:
+					extra = fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error()))
+				default:
+					extra = tview.Escape(strings.TrimSpace(output))
+				}
+				extraDone = true
+				finish()
+			})
+		}
+		loadingDetails = loading
+		loading.render(spinnerFrames[0])
+		loading.Start(appCtx)
+	}
+
+	// renderDetails shows an option's details, after their substitutions, with
+	// extra, such as its detailsCmd output, below them. The template is only
+	// rendered now so fields filled in by it are never run as $(...).
+	renderDetails = func(option Option, details string, extra string) {
+		if *templatedDetails || option.DetailsFormat == "template" {
+			details = templateDetails(details, option)
+		}
+		if extra != "" {
+			details = strings.TrimSpace(details + "\n\n" + extra)
+		}
+		if compactInfo {
+			infoBox.SetText(compactDetails(option, details, previewCommand(option.Command)))
+			return
+		}
+		full := details
+		details, truncated := truncateDetails(details, *detailsMaxBytes, *detailsMaxLines)
+		if *markdownDetails || option.DetailsFormat == "markdown" {
+			details = markdownToTview(details)
+			full = markdownToTview(full)
+		}
+		fullDetails = ""
+		if truncated {
+			fullDetails = full
+			details += "\n[gray]" + msg.get("truncated") + "[-]"
+		}
+		if option.Disabled {
+			details = strings.TrimSpace("[gray]" + msg.get("disabled", option.DisabledReason) + "[-]\n" + details)
+		} else if isEmptyCategory(option) {
+			details = strings.TrimSpace("[gray]" + msg.get("emptyCategory") + "[-]\n" + details)
+		} else if isMissingCommand(option) {
+			details = strings.TrimSpace("[gray]" + msg.get("noCommand") + "[-]\n" + details)
+		}
+		infoBox.SetText(details)
 	}
 
 	// Initial population
 	populateList()
+	if len(rootOptions) == 0 {
+		infoBox.SetText(menuHint())
+	}
 
 	// Update bottom panel when selection changes
 	list.SetChangedFunc(func(index int, mainText string, _ string, _ rune) {
+		rows := menuRows
 		if searchMode {
-			if index >= 0 && index < len(searchResults) {
-				infoBox.SetText(searchResults[index].Details)
+			rows = searchRows
+		}
+		if index < 0 || index >= len(rows) {
+			return
+		}
+
+		if !rows[index].Selectable() {
+			// Landed on a header or disabled option, move on in the direction of travel
+			step := 1
+			if index < previousIndex {
+				step = -1
 			}
-		} else {
-			if index >= 0 && index < len(currentOptions) {
-				infoBox.SetText(currentOptions[index].Details)
+			if next := selectableRow(rows, index, step); next >= 0 && next < list.GetItemCount() {
+				list.SetCurrentItem(next)
+				return
 			}
 		}
+		previousIndex = index
+		items.Mark(index)
+		if !searchMode && len(letters) > 0 {
+			current, _ := firstLetter(rows[index].Option.Title)
+			letterBar.SetText(renderLetters(letters, current))
+		}
+		if rows[index].Option != nil {
+			showDetails(*rows[index].Option)
+		}
 	})
 
+	// Grid rows for the current info box height, with or without an input
+	// row above the list, remembered so a resize can lay the grid out again
+	infoHeight := defaultInfoBoxHeight
+	var inputRow bool
+	gridRows := func(withInput bool) []int {
+		inputRow = withInput
+		return layoutRows(withInput, infoHeight, compactInfo)
+	}
+
 	// Grid layout
 	grid = tview.NewGrid().
-		SetRows(0, 5).
+		SetRows(gridRows(false)...).
 		SetColumns(0).
 		SetBorders(true).
-		SetBordersColor(tcell.ColorWhite).
-		AddItem(list, 0, 0, 1, 1, 0, 0, true).
+		SetBordersColor(colors.Border).
+		AddItem(listPane, 0, 0, 1, 1, 0, 0, true).
 		AddItem(infoBox, 1, 0, 1, 1, 0, 0, false)
 	
-	grid.SetBackgroundColor(tcell.ColorDefault)
+	grid.SetBackgroundColor(colors.Background)
+	pages = tview.NewPages().AddPage("main", grid, true, true)
+	pages.SetBackgroundColor(colors.Background)
+
+	// Mark or unmark the selected command to run it together with others
+	toggleMark := func() {
+		option, ok := selectedOption()
+		if !ok {
+			return
+		}
+		if option.Path == "" {
+			option.Path = currentPath
+		}
+		if !canMark(option) {
+			infoBox.SetText("[gray]" + tview.Escape(msg.get("cantMark", option.Title)) + "[-]")
+			return
+		}
+		marked.Toggle(option)
+		index := list.GetCurrentItem()
+		populateList()
+		list.SetCurrentItem(index)
+		if len(marked) > 0 {
+			infoBox.SetText(msg.get("marked", len(marked)))
+		}
+	}
+
+	// Switch the info box between full details and a single line footer, the
+	// choice holds until it's switched back
+	toggleCompactInfo := func() {
+		compactInfo = !compactInfo
+		grid.SetRows(gridRows(inputRow)...)
+		if option, ok := selectedOption(); ok {
+			showDetails(option)
+		}
+	}
 
 	// Assign the function implementations
 	showParameterPrompts = func(option Option, parameters []Parameter) {
@@ -327,6 +1961,113 @@ func main() {
 		showNextParameterPrompt()
 	}
 	
+	// Options that declare Args get all their arguments in one form instead of a prompt each
+	buildArgumentForm := func(option Option, parameters []Parameter, choices map[string][]string) {
+		parameterMode = true
+		sort.SliceStable(parameters, func(i, j int) bool {
+			return parameters[i].Index < parameters[j].Index
+		})
+
+		form := tview.NewForm()
+		form.SetBackgroundColor(colors.Background)
+		optionPath := joinPath(option.Path, option.Title)
+		var fields []func() string // values of the form's items
+		var fieldParameters []Parameter
+		seen := make(map[string]bool)
+		for _, param := range parameters {
+			// A placeholder used more than once is filled in once
+			if seen[param.Placeholder] {
+				continue
+			}
+			seen[param.Placeholder] = true
+
+			arg, _ := argFor(option, param.Label)
+			value := argValues.value(optionPath, arg)
+			label := param.Label
+			if arg.Required {
+				label += " *"
+			}
+			fieldParameters = append(fieldParameters, param)
+			if values := choices[arg.Name]; len(values) > 0 {
+				// Picked from the optionsCmd's output instead of typed
+				dropDown := tview.NewDropDown().
+					SetLabel(label+": ").
+					SetOptions(values, nil).
+					SetCurrentOption(choiceIndex(values, value))
+				form.AddFormItem(dropDown)
+				fields = append(fields, func() string {
+					_, value := dropDown.GetCurrentOption()
+					return value
+				})
+				continue
+			}
+			field := tview.NewInputField().SetLabel(label + ": ").SetText(value)
+			if arg.Secret {
+				field.SetMaskCharacter('*')
+			}
+			form.AddFormItem(field)
+			fields = append(fields, field.GetText)
+		}
+
+		form.AddButton(msg.get("run"), func() {
+			var texts []string
+			for _, field := range fields {
+				texts = append(texts, field())
+			}
+			values, entered, failed, err := argumentValues(option, fieldParameters, texts)
+			if err != nil {
+				infoBox.SetText(fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error())))
+				form.SetFocus(failed)
+				app.SetFocus(form)
+				return
+			}
+			if !option.NoHistory {
+				argValues.remember(optionPath, option.Args, entered)
+				argValuesErr = saveLastArgs(lastArgsPath, argValues)
+			}
+			executeCommandWithParameters(option, parameters, values)
+		})
+		form.AddButton(msg.get("cancel"), func() {
+			switchToMainMenu()
+		})
+
+		grid.Clear().
+			SetRows(gridRows(false)...).
+			SetColumns(0).
+			SetBorders(true).
+			SetBordersColor(colors.Border).
+			AddItem(form, 0, 0, 1, 1, 0, 0, true).
+			AddItem(infoBox, 1, 0, 1, 1, 0, 0, false)
+
+		infoBox.SetText(msg.get("fillArguments", tview.Escape(option.Title)))
+		app.SetFocus(form)
+	}
+
+	// Args with an optionsCmd are listed in the background first, so the spinner keeps moving
+	loadingChoices := false
+	showArgumentForm = func(option Option, parameters []Parameter) {
+		if !needsChoices(option) {
+			buildArgumentForm(option, parameters, nil)
+			return
+		}
+		if loadingChoices {
+			return
+		}
+		loadingChoices = true
+		loading := startSpinner(appCtx, app, infoBox, msg.get("loading", option.Title))
+		go func() {
+			choices, errs := loadChoices(appCtx, shellOutput, option.Args)
+			app.QueueUpdateDraw(func() {
+				loading.Stop()
+				loadingChoices = false
+				buildArgumentForm(option, parameters, choices)
+				if len(errs) > 0 {
+					infoBox.SetText("[red]" + tview.Escape(errors.Join(errs...).Error()) + "[-]")
+				}
+			})
+		}()
+	}
+
 	showNextParameterPrompt = func() {
 		if currentParameterIndex >= len(currentParameters) {
 			// All parameters collected, execute the command
@@ -339,7 +2080,10 @@ func main() {
 		// Create parameter input field
 		paramInput := tview.NewInputField().
 			SetLabel(fmt.Sprintf("%s: ", param.Label))
-		paramInput.SetBackgroundColor(tcell.ColorDefault)
+		paramInput.SetBackgroundColor(colors.Background)
+		if arg, ok := argFor(currentParameterOption, param.Label); ok && arg.Secret {
+			paramInput.SetMaskCharacter('*')
+		}
 		
 		// Set the done function after creating the input field
 		paramInput.SetDoneFunc(func(key tcell.Key) {
@@ -354,12 +2098,12 @@ func main() {
 		
 		// Modify grid layout to add parameter input at the top
 		grid.Clear().
-			SetRows(1, 0, 5).
+			SetRows(gridRows(true)...).
 			SetColumns(0).
 			SetBorders(true).
-			SetBordersColor(tcell.ColorWhite).
+			SetBordersColor(colors.Border).
 			AddItem(paramInput, 0, 0, 1, 1, 0, 0, true).
-			AddItem(list, 1, 0, 1, 1, 0, 0, false).
+			AddItem(listPane, 1, 0, 1, 1, 0, 0, false).
 			AddItem(infoBox, 2, 0, 1, 1, 0, 0, false)
 		
 		app.SetFocus(paramInput)
@@ -370,30 +2114,320 @@ func main() {
 			return
 		}
 		
-		// Replace all parameters with their values
-		expandedCommand := option.Command
-		for _, param := range parameters {
-			if value, exists := values[param.Placeholder]; exists {
-				expandedCommand = strings.ReplaceAll(expandedCommand, param.Placeholder, value)
+		executeCommand(option, fillParameters(option.Command, parameters, values))
+	}
+
+	// Ask before running a command, the menu comes back when it's cancelled
+	confirming := false
+	confirmCommand := func(option Option, command string, run func()) {
+		confirming = true
+		finish := func(confirmed bool) {
+			confirming = false
+			pages.RemovePage("confirm")
+			app.SetFocus(list)
+			if confirmed {
+				run()
+			} else if parameterMode {
+				switchToMainMenu()
 			}
 		}
-		
-		expandedCommand = expandCommand(expandedCommand)
-		fmt.Print(expandedCommand)
-		app.Stop()
+		text := msg.get("confirmRun", tview.Escape(option.Title), tview.Escape(command))
+		if option.ConfirmPhrase == "" {
+			modal := tview.NewModal().
+				SetText(text).
+				AddButtons([]string{msg.get("run"), msg.get("cancel")}).
+				SetDoneFunc(func(index int, _ string) { finish(index == 0) })
+			if color, ok := colors.levelColor(option.Level); ok {
+				modal.SetBorderColor(color)
+			}
+			pages.AddPage("confirm", modal, true, true)
+			app.SetFocus(modal)
+			return
+		}
+
+		form := phraseForm(option.ConfirmPhrase, msg, finish)
+		message := tview.NewTextView().SetText(text).SetTextAlign(tview.AlignCenter)
+		dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(message, 0, 1, false).
+			AddItem(form, 5, 0, true)
+		dialog.SetBorder(true)
+		if color, ok := colors.levelColor(option.Level); ok {
+			dialog.SetBorderColor(color)
+		}
+		width := max(50, utf8.RuneCountInString(command)+4)
+		centered := tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(dialog, strings.Count(text, "\n")+8, 0, true).
+				AddItem(nil, 0, 1, false), width, 0, true).
+			AddItem(nil, 0, 1, false)
+		pages.AddPage("confirm", centered, true, true)
+		app.SetFocus(form)
+	}
+
+	// run or print a command and stop the app, the app keeps running if the command can't be emitted
+	runCommand := func(option Option, command string, expandedCommand string) {
+		if err := runHook(option); err != nil {
+			hookErr = err
+		}
+		keepOpen := keepsMenuOpen(option, *execMode, *loopMode)
+		switch {
+		case keepOpen:
+			// Run below while the UI is suspended
+		case *execMode:
+			// Run after the UI has released the terminal
+			execOption, execCommand = &option, expandedCommand
+		default:
+			// Printed once the UI is gone, see finishMenu
+			printCommand = printedCommand(option, expandedCommand, *configShell)
+			selectedExitCode = option.ExitCode
+		}
+		selected = true
+		chosenOption = option
+		recordUsage(option, expandedCommand)
+		last.Record(option, command)
+		if !keepOpen {
+			app.Stop()
+			return
+		}
+
+		var code int
+		var tail *tailBuffer
+		var output io.Writer // Only captured when asked, it takes the terminal away from the command
+		if *outputLines > 0 {
+			tail = &tailBuffer{Max: *outputLines}
+			output = tail
+		}
+		app.Suspend(func() {
+			emitPath(pathOut, option)
+			code = runSelected(option, expandedCommand, *configShell, *notifyAll, execTimeout, output)
+			waitForEnter(os.Stdin, os.Stdout)
+		})
+		if parameterMode {
+			switchToMainMenu()
+		}
+		var lines []string
+		if tail != nil {
+			lines = tail.Lines()
+		}
+		infoBox.SetText(commandStatus(msg, option, code, lines))
+	}
+
+	// Run the marked commands one after another once their summary is
+	// confirmed, in loop mode the menu comes back afterwards
+	runMarked = func() {
+		commands := marked.expanded(previewCommand)
+		confirming = true
+		dialog := batchDialog(batchSummary(msg, commands), msg, func(confirmed bool) {
+			confirming = false
+			pages.RemovePage("confirm")
+			app.SetFocus(list)
+			if !confirmed {
+				return
+			}
+			marked = nil
+			for _, option := range commands {
+				if err := runHook(option); err != nil {
+					hookErr = err
+				}
+				recordUsage(option, option.Command)
+			}
+			if !*execMode || !*loopMode {
+				// Printed or run once the UI has closed, see chosenBatch
+				var printed []string
+				for _, option := range commands {
+					printed = append(printed, printedCommand(option, option.Command, *configShell))
+				}
+				if !*execMode {
+					printCommand = strings.Join(printed, "\n")
+				}
+				chosenBatch = commands
+				selected = true
+				app.Stop()
+				return
+			}
+
+			var statuses []string
+			app.Suspend(func() {
+				runBatch(commands, func(option Option) int {
+					emitPath(pathOut, option)
+					code := runSelected(option, option.Command, *configShell, *notifyAll, execTimeout, nil)
+					statuses = append(statuses, commandStatus(msg, option, code, nil))
+					return code
+				})
+				waitForEnter(os.Stdin, os.Stdout)
+			})
+			index := list.GetCurrentItem()
+			populateList()
+			list.SetCurrentItem(index)
+			infoBox.SetText(strings.Join(statuses, "\n"))
+		})
+		width := 50
+		for _, option := range commands {
+			width = max(width, utf8.RuneCountInString(option.Command)+8)
+		}
+		centered := tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(dialog, min(2*len(commands)+7, 20), 0, true).
+				AddItem(nil, 0, 1, false), width, 0, true).
+			AddItem(nil, 0, 1, false)
+		pages.AddPage("confirm", centered, true, true)
+		app.SetFocus(dialog)
+	}
+
+	// execute command, asking first when the option or the config's confirmPattern wants it
+	executeCommand = func(option Option, command string) {
+		if isBlankCommand(command) {
+			infoBox.SetText("[gray]" + msg.get("noCommand") + "[-]")
+			return
+		}
+		// Resolve variables from the env file here, the parent shell doesn't have them
+		expandedCommand := expandCommand(expandEnvVars(command, envFileNames))
+		if *twoStep {
+			if !pendingRun.Confirm(joinPath(option.Path, option.Title), command) {
+				infoBox.SetText("[yellow::b]" + msg.get("runAgain") + "[-::-]\n" + tview.Escape(printedCommand(option, expandedCommand, *configShell)))
+				return
+			}
+		}
+		if needsConfirmation(option, expandedCommand, confirmPattern) {
+			confirmCommand(option, expandedCommand, func() { runCommand(option, command, expandedCommand) })
+			return
+		}
+		runCommand(option, command, expandedCommand)
+	}
+
+	// Run the last executed option again, prompting for parameters if they weren't filled in
+	rerunLastCommand := func() {
+		if !last.Rerun(executeCommand, handleCommand) {
+			infoBox.SetText(msg.get("nothingRun"))
+		}
+	}
+
+	// Edit the selected option's command before running it, Escape cancels like
+	// it does for parameter prompts
+	editSelectedCommand := func() {
+		option, ok := selectedOption()
+		if !ok || option.Command == "" || option.Disabled {
+			return
+		}
+		parameterMode = true
+
+		input := tview.NewInputField().
+			SetLabel(msg.get("editLabel")).
+			SetText(previewCommand(option.Command))
+		input.SetBackgroundColor(colors.Background)
+		input.SetDoneFunc(func(key tcell.Key) {
+			edited, ok := editCommand(option, input.GetText())
+			if key != tcell.KeyEnter || !ok {
+				return
+			}
+			if parameters := parseParameters(edited.Command); len(parameters) > 0 {
+				// Placeholders left in the edited command are still asked for
+				showParameterPrompts(edited, parameters)
+				return
+			}
+			executeCommand(option, edited.Command)
+		})
+
+		grid.Clear().
+			SetRows(gridRows(true)...).
+			SetColumns(0).
+			SetBorders(true).
+			SetBordersColor(colors.Border).
+			AddItem(input, 0, 0, 1, 1, 0, 0, true).
+			AddItem(listPane, 1, 0, 1, 1, 0, 0, false).
+			AddItem(infoBox, 2, 0, 1, 1, 0, 0, false)
+		infoBox.SetText(msg.get("editCommand"))
+		app.SetFocus(input)
+	}
+
+	// Open a prompt for a menu path and jump there, Escape cancels like it
+	// does for parameter prompts
+	goToPath := func() {
+		parameterMode = true
+
+		input := tview.NewInputField().SetLabel(msg.get("gotoLabel"))
+		input.SetBackgroundColor(colors.Background)
+		input.SetDoneFunc(func(key tcell.Key) {
+			if key != tcell.KeyEnter {
+				return
+			}
+			visit, selected, err := resolveMenuPath(rootOptions, input.GetText(), msg.get("mainMenu"))
+			if err != nil {
+				infoBox.SetText(fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error())))
+				return
+			}
+			switchToMainMenu()
+			goToVisit(visit)
+			if index, ok := defaultChildIndex(menuRows, selected); ok && selected != "" {
+				list.SetCurrentItem(index)
+			}
+		})
+
+		grid.Clear().
+			SetRows(gridRows(true)...).
+			SetColumns(0).
+			SetBorders(true).
+			SetBordersColor(colors.Border).
+			AddItem(input, 0, 0, 1, 1, 0, 0, true).
+			AddItem(listPane, 1, 0, 1, 1, 0, 0, false).
+			AddItem(infoBox, 2, 0, 1, 1, 0, 0, false)
+		infoBox.SetText(msg.get("gotoMode"))
+		app.SetFocus(input)
+	}
+
+
+	// Count the execution and refresh the "Most used" category
+	recordUsage = func(option Option, command string) {
+		if !shouldRecord(option, command, redactPattern) {
+			return
+		}
+		recordExecution(stats, option, time.Now())
+		statsErr = saveStats(statsPath, stats)
+		rootOptions = rootMenu()
+		if len(menuStack) == 0 {
+			currentOptions = rootOptions
+		}
+	}
+
+	// Open an option's editFile while the UI is suspended, then come back to the menu
+	editFile := func(option Option) {
+		path := editFilePath(option.EditFile)
+		var err error
+		app.Suspend(func() {
+			err = runEditor(editorCommand(os.Getenv("EDITOR"), path))
+		})
+		if err != nil {
+			infoBox.SetText(fmt.Sprintf("[red]%s[-]", tview.Escape(err.Error())))
+			return
+		}
+		infoBox.SetText(msg.get("edited", tview.Escape(path)))
 	}
 
 	handleCommand = func(option Option) {
-		if len(option.Command) == 0 {
+		if opensEditor(option) {
+			editFile(option)
+			return
+		}
+		if isMissingCommand(option) {
+			infoBox.SetText("[gray]" + msg.get("noCommand") + "[-]")
+			return
+		}
+		if !isRunnable(option) {
 			return
 		}
 		
 		// Check if command contains ${n:label} parameters
 		parameters := parseParameters(option.Command)
-		if len(parameters) > 0 {
+		if len(parameters) > 0 && len(option.Args) > 0 {
+			showArgumentForm(option, parameters)
+		} else if len(parameters) > 0 {
 			showParameterPrompts(option, parameters)
 		} else {
-			executeCommand(option, app)
+			executeCommand(option, option.Command)
 		}
 	}
 
@@ -409,16 +2443,16 @@ func main() {
 		searchQuery = ""
 		searchInput.SetText("")
 		grid.Clear().
-			SetRows(1, 0, 5).
+			SetRows(gridRows(true)...).
 			SetColumns(0).
 			SetBorders(true).
-			SetBordersColor(tcell.ColorWhite).
+			SetBordersColor(colors.Border).
 			AddItem(searchInput, 0, 0, 1, 1, 0, 0, true).
-			AddItem(list, 1, 0, 1, 1, 0, 0, false).
+			AddItem(listPane, 1, 0, 1, 1, 0, 0, false).
 			AddItem(infoBox, 2, 0, 1, 1, 0, 0, false)
 		app.SetFocus(searchInput)
 		populateSearchResults()
-		infoBox.SetText("Search mode - type to filter options")
+		infoBox.SetText(msg.get("searchMode"))
 		
 		// Add custom input capture for list in search mode
 		list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -432,10 +2466,10 @@ func main() {
 			}
 			// Handle Enter to execute command
 			if event.Key() == tcell.KeyEnter {
-				if len(searchResults) > 0 && list.GetCurrentItem() >= 0 {
+				if len(searchRows) > 0 && list.GetCurrentItem() >= 0 {
 					selectedIndex := list.GetCurrentItem()
-					if selectedIndex < len(searchResults) {
-						handleCommand(searchResults[selectedIndex])
+					if selectedIndex < len(searchRows) && searchRows[selectedIndex].Option != nil {
+						handleCommand(*searchRows[selectedIndex].Option)
 					}
 				}
 				return nil
@@ -444,60 +2478,244 @@ func main() {
 		})
 	}
 
-	// Function to switch back to main menu (unified for both search and parameter modes)
-	switchToMainMenu := func() {
+	// Filter input field, narrows the current menu
+	filterInput := tview.NewInputField().
+		SetLabel(msg.get("filterLabel"))
+	filterInput.SetBackgroundColor(colors.Background)
+	filterInput.SetChangedFunc(func(text string) {
+		menuFilter = text
+		populateList()
+	})
+	filterInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Up/Down move through the filtered menu and Enter activates its selection
+		index := list.GetCurrentItem()
+		switch event.Key() {
+		case tcell.KeyUp:
+			if index > 0 {
+				list.SetCurrentItem(selectableRow(menuRows, index-1, -1))
+			}
+			return nil
+		case tcell.KeyDown:
+			if index < len(menuRows)-1 {
+				list.SetCurrentItem(selectableRow(menuRows, index+1, 1))
+			}
+			return nil
+		case tcell.KeyEnter:
+			if index >= 0 && index < len(menuRows) && menuRows[index].Selectable() {
+				if selected := list.GetItemSelectedFunc(index); selected != nil {
+					selected()
+				}
+			}
+			return nil
+		}
+		return event
+	})
+
+	// Function to filter the current menu in place
+	switchToFilterMode := func() {
+		filterMode = true
+		menuFilter = ""
+		filterInput.SetText("")
+		grid.Clear().
+			SetRows(gridRows(true)...).
+			SetColumns(0).
+			SetBorders(true).
+			SetBordersColor(colors.Border).
+			AddItem(filterInput, 0, 0, 1, 1, 0, 0, true).
+			AddItem(listPane, 1, 0, 1, 1, 0, 0, false).
+			AddItem(infoBox, 2, 0, 1, 1, 0, 0, false)
+		app.SetFocus(filterInput)
+		infoBox.SetText(msg.get("filterMode", currentTitle))
+	}
+
+	// Function to switch back to main menu (unified for search, filter and parameter modes)
+	switchToMainMenu = func() {
 		// Reset all modes
 		searchMode = false
 		parameterMode = false
+		filterMode = false
 		searchQuery = ""
+		menuFilter = ""
 		
 		// Reset parameter state
 		currentParameterIndex = 0
 		parameterValues = make(map[string]string)
 		
-		// Clear the list input capture to restore normal behavior
-		list.SetInputCapture(nil)
+		// Restore the list's normal mode input capture
+		list.SetInputCapture(normalListCapture)
 		
 		// Restore original grid layout
 		grid.Clear().
-			SetRows(0, 5).
+			SetRows(gridRows(false)...).
 			SetColumns(0).
 			SetBorders(true).
-			SetBordersColor(tcell.ColorWhite).
-			AddItem(list, 0, 0, 1, 1, 0, 0, true).
+			SetBordersColor(colors.Border).
+			AddItem(listPane, 0, 0, 1, 1, 0, 0, true).
 			AddItem(infoBox, 1, 0, 1, 1, 0, 0, false)
 		
 		app.SetFocus(list)
 		populateList()
-		infoBox.SetText("Select an option from " + currentTitle)
+		infoBox.SetText(menuHint())
+	}
+
+	// pressed reports whether event is one of the keys of action
+	pressed := func(event *tcell.EventKey, action string) bool {
+		return pressedAction(keys, event, action)
 	}
 
 	// Global input capture for navigation and quit
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		
-		// 'q' always quits the application
-		if event.Key() == tcell.KeyRune && event.Rune() == 'q' {
+		// The confirmation dialog handles its own keys
+		if confirming {
+			return event
+		}
+
+		// Keys are the defaults below unless the config's "keys" changes them.
+		// Inputs get them as text like any other key.
+		// 'q' quits the application
+		if pressed(event, "quit") && !searchMode && !parameterMode && !filterMode {
 			app.Stop()
 			return nil
 		}
 		// '?' enters search mode
-		if event.Key() == tcell.KeyRune && event.Rune() == '?' {
+		if pressed(event, "search") && !searchMode && !parameterMode && !filterMode {
 			switchToSearchMode()
 			return nil
 		}
-		// Escape: go back if in submenu, quit if at top level, exit modes if in search/parameter mode
-		if event.Key() == tcell.KeyEscape {
-			if searchMode || parameterMode {
+		// Alt-Left/Alt-Right move back and forward through visited menus
+		if event.Modifiers()&tcell.ModAlt != 0 && !searchMode && !parameterMode && !filterMode {
+			if event.Key() == tcell.KeyLeft {
+				leaveMenu()
+				restoreVisit(history.Back())
+				return nil
+			}
+			if event.Key() == tcell.KeyRight {
+				leaveMenu()
+				restoreVisit(history.Forward())
+				return nil
+			}
+			// Alt+letter jumps to the first item starting with the letter
+			if event.Key() == tcell.KeyRune {
+				if index, ok := letterTarget(letters, event.Rune()); ok {
+					list.SetCurrentItem(index)
+				}
+				return nil
+			}
+		}
+		// Chords run their option from any menu, the keys pressed so far list the ones left
+		if event.Key() == tcell.KeyRune && !searchMode && !parameterMode && !filterMode && app.GetFocus() == list {
+			if c, complete, used := chords.Press(event.Rune(), time.Now()); used {
+				if complete {
+					handleCommand(c.Option)
+				} else {
+					infoBox.SetText("[gray]" + tview.Escape(chordHints(chords.Pending())) + "[-]")
+				}
+				return nil
+			}
+		}
+		// '~' jumps back to the main menu
+		if pressed(event, "root") && !searchMode && !parameterMode && !filterMode {
+			goToRoot()
+			return nil
+		}
+		// '.' runs the last executed option again
+		if pressed(event, "rerun") && !searchMode && !parameterMode && !filterMode {
+			rerunLastCommand()
+			return nil
+		}
+		// ':' prompts for a menu path to jump to
+		if pressed(event, "goto") && !searchMode && !parameterMode && !filterMode {
+			goToPath()
+			return nil
+		}
+		// 'f' filters the current menu
+		if pressed(event, "filter") && !searchMode && !parameterMode && !filterMode {
+			switchToFilterMode()
+			return nil
+		}
+		// '>' opens the selected category, also one that runs its command on Enter
+		if pressed(event, "open") && !searchMode && !parameterMode && !filterMode {
+			if option, ok := selectedOption(); ok && !option.Disabled {
+				openCategory(option)
+			}
+			return nil
+		}
+		// Right opens the selected category when Enter only previews it
+		if event.Key() == tcell.KeyRight && categoryKeyOpens(config.CategoryEnter, tcell.KeyRight) &&
+			!searchMode && !parameterMode && !filterMode && app.GetFocus() == list {
+			if option, ok := selectedOption(); ok && !option.Disabled {
+				openCategory(option)
+			}
+			return nil
+		}
+		// 'e' edits the selected command before running it
+		if pressed(event, "edit") && !searchMode && !parameterMode && !filterMode {
+			editSelectedCommand()
+			return nil
+		}
+		// 'Y' copies the selected option's details, Ctrl-Y in search mode so 'Y' can still be typed
+		if (pressed(event, "copy") && !searchMode && !parameterMode && !filterMode) ||
+			(event.Key() == tcell.KeyCtrlY && searchMode) {
+			copySelectedDetails()
+			return nil
+		}
+		// 'D' switches the info box between details and the debug view
+		if pressed(event, "debug") && !searchMode && !parameterMode && !filterMode {
+			toggleDebugView()
+			return nil
+		}
+		// Space marks the selected command to run together with others
+		if pressed(event, "mark") && !searchMode && !parameterMode && !filterMode && app.GetFocus() == list {
+			toggleMark()
+			return nil
+		}
+		// 'C' switches the info box between full details and a one line footer
+		if pressed(event, "compact") && !searchMode && !parameterMode && !filterMode {
+			toggleCompactInfo()
+			return nil
+		}
+		// 'P' copies the selected option's path, Ctrl-P in search mode
+		if (pressed(event, "copyPath") && !searchMode && !parameterMode && !filterMode) ||
+			(event.Key() == tcell.KeyCtrlP && searchMode) {
+			copySelectedPath()
+			return nil
+		}
+		// Tab scrolls the full details of the selected option
+		if event.Key() == tcell.KeyTab && !searchMode && !parameterMode && !filterMode {
+			if app.GetFocus() == infoBox {
+				unfocusDetails()
+			} else {
+				focusDetails()
+			}
+			return nil
+		}
+		// Escape cancels a pending two-step run, parameter prompts are left as usual
+		if event.Key() == tcell.KeyEscape && pendingRun.Cancel() {
+			if !parameterMode {
+				if option, ok := selectedOption(); ok {
+					showDetails(option)
+				}
+				return nil
+			}
+		}
+		// Escape: go back if in submenu, quit if at top level, exit modes if in search/filter/parameter mode.
+		// The back keys do the same in menus.
+		if event.Key() == tcell.KeyEscape || (pressed(event, "back") && !searchMode && !parameterMode && !filterMode) {
+			if app.GetFocus() == infoBox {
+				unfocusDetails()
+			} else if searchMode || parameterMode || filterMode {
 				switchToMainMenu()
 			} else if len(menuStack) > 0 {
 				// Go back to previous menu
+				leaveMenu()
 				currentOptions = menuStack[len(menuStack)-1]
 				menuStack = menuStack[:len(menuStack)-1]
+				currentPath = parentPath(currentPath)
 				if len(menuStack) == 0 {
-					currentTitle = "Main Menu"
+					currentTitle = msg.get("mainMenu")
 				} else {
 					// Find the title of the parent menu
-					currentTitle = "Main Menu" // fallback
+					currentTitle = msg.get("mainMenu") // fallback
 					for _, opt := range rootOptions {
 						if containsOption(opt.Children, currentOptions) {
 							currentTitle = opt.Title
@@ -505,8 +2723,9 @@ func main() {
 						}
 					}
 				}
+				enterMenu()
 				populateList()
-				infoBox.SetText("Select an option from " + currentTitle)
+				infoBox.SetText(menuHint())
 			} else {
 				// At top level, quit the application
 				app.Stop()
@@ -516,13 +2735,124 @@ func main() {
 		return event
 	})
 
-	// Respect terminal background
+	// Respect terminal background unless the config turned it off, and fit the
+	// layout to the terminal again when it was resized
+	lastHeight := 0
 	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
-		screen.Clear()
+		_, height := screen.Size()
+		relayout, clear := beforeDraw(height, lastHeight, respectBackground)
+		if relayout {
+			lastHeight = height
+			infoHeight = infoBoxHeight(height)
+			grid.SetRows(gridRows(inputRow)...)
+		}
+		if clear {
+			screen.Clear()
+		}
 		return false
 	})
 
-	if err := app.SetRoot(grid, true).SetFocus(list).Run(); err != nil {
+	// -s opens the menu searching, --select-single picks a single result as
+	// soon as the UI runs so it can still ask for parameters
+	if *initialSearch != "" {
+		switchToSearchMode()
+		searchInput.SetText(*initialSearch)
+		if only, ok := singleResult(searchResults, *selectSingle); ok {
+			// QueueUpdateDraw waits for the update, which only runs once the app does
+			go app.QueueUpdateDraw(func() {
+				handleCommand(only)
+			})
+		}
+	}
+
+	// Add the plugins to the main menu once they have loaded, their errors are
+	// shown in the info box since the terminal belongs to the UI by then
+	if loadingPlugins {
+		update := func(f func()) { app.QueueUpdateDraw(f) }
+		loadInBackground(loadPluginOptions, update, func(loaded []Option, pluginErrs []error) {
+			loadingPlugins = false
+			configOptions = prepareOptions(loaded)
+			var problems []string
+			for _, err := range pluginErrs {
+				problems = append(problems, err.Error())
+			}
+			index, err := buildIndex(loaded)
+			if err != nil {
+				problems = append(problems, err.Error())
+			}
+			allOptions = index
+			// Problems with the config's own chords were reported at startup
+			chords.Chords, _ = collectChords(configOptions, keys)
+
+			rootOptions = rootMenu()
+			if searchMode {
+				populateSearchResults()
+			}
+			if len(menuStack) == 0 {
+				if searchMode || parameterMode || filterMode {
+					// Shown when the main menu is back on screen
+					currentOptions = rootOptions
+				} else {
+					previous, index := menuRows, list.GetCurrentItem()
+					currentOptions = rootOptions
+					populateList()
+					list.SetCurrentItem(preserveSelection(previous, index, menuRows))
+				}
+			}
+			if len(problems) > 0 {
+				infoBox.SetText("[red]" + tview.Escape(strings.Join(problems, "\n")) + "[-]")
+			}
+		})
+	}
+
+	if err := app.SetRoot(pages, true).SetFocus(list).Run(); err != nil {
 		panic(err)
 	}
+	cancelApp()
+
+	if statsErr != nil {
+		warn("stats not saved, %v", statsErr)
+	}
+	if hookErr != nil {
+		warn("%v", hookErr)
+	}
+	if argValuesErr != nil {
+		warn("last arguments not saved, %v", argValuesErr)
+	}
+
+	exitCode, err := finishMenu(selected, printCommand, selectedExitCode, func(command string) error {
+		return emitCommand(command, *outPath)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !selected {
+		os.Exit(exitCode)
+	}
+	if len(chosenBatch) > 0 {
+		for _, option := range chosenBatch {
+			emitPath(pathOut, option)
+		}
+	} else if !keepsMenuOpen(chosenOption, *execMode, *loopMode) {
+		// Commands kept open wrote their path as they ran
+		emitPath(pathOut, chosenOption)
+	}
+
+	if *execMode && len(chosenBatch) > 0 {
+		os.Exit(runBatch(chosenBatch, func(option Option) int {
+			code := runSelected(option, option.Command, *configShell, *notifyAll, execTimeout, nil)
+			printPostMessage(os.Stderr, option, code)
+			return code
+		}))
+	}
+
+	if execOption != nil {
+		code := runSelected(*execOption, execCommand, *configShell, *notifyAll, execTimeout, nil)
+		printPostMessage(os.Stderr, *execOption, code)
+		os.Exit(code)
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
@@ -0,0 +1,1078 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		want  int
+	}{
+		{"git", "git", 100},
+		{"git", "github", 90},
+		{"hub", "git hub", 75},
+		{"hub", "git-hub", 75},
+		{"hub", "github", 60},
+		{"gtb", "gitbranch", 35}, // the i is skipped
+		{"gb", "g" + strings.Repeat("x", 10) + "b", 1},
+		{"über", "zu über", 75},
+		{"üb", "über", 90},
+		{"ür", "über", 30}, // counted in runes, not bytes
+		{"xyz", "github", 0},
+		{"", "github", 0},
+	}
+	for _, tt := range tests {
+		if got := fuzzyScore(tt.query, tt.text); got != tt.want {
+			t.Errorf("fuzzyScore(%q, %q) = %d, want %d", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEffectiveMinScore(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"a", 6},
+		{"ab", 13},
+		{"abc", 20},
+		{"äö", 13}, // counted in runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := effectiveMinScore(tt.query, 20); got != tt.want {
+			t.Errorf("effectiveMinScore(%q, 20) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMinScorePrunesWeakMatches(t *testing.T) {
+	options := []Option{
+		{Title: "log", Command: "git log"},
+		{Title: "logout", Command: "exit"},
+		{Title: "blog", Command: "open blog"},
+		{Title: "l-o-n-g", Command: "true"}, // l, o and g spread out
+	}
+	index := newOptionIndex(options)
+
+	tests := []struct {
+		minScore int
+		want     []string
+	}{
+		{0, []string{"log", "logout", "blog", "l-o-n-g"}},
+		{defaultMinScore, []string{"log", "logout", "blog", "l-o-n-g"}},
+		{50, []string{"log", "logout", "blog"}},
+		// Exact and prefix matches survive even a strict threshold
+		{90, []string{"log", "logout"}},
+		{100, []string{"log"}},
+	}
+	for _, tt := range tests {
+		if got := titles(filterOptions(options, "log", tt.minScore)); !slices.Equal(got, tt.want) {
+			t.Errorf("filterOptions(minScore=%d) = %q, want %q", tt.minScore, got, tt.want)
+		}
+		// Search ranks instead of keeping menu order, the matches are the same
+		got := titles(index.Search("log", searchSettings{MinScore: tt.minScore}))
+		if !slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(tt.want))) {
+			t.Errorf("Search(minScore=%d) = %q, want %q in any order", tt.minScore, got, tt.want)
+		}
+	}
+}
+
+func TestOptionAtPicksTheSelectedRowsDetails(t *testing.T) {
+	results := []Option{
+		{Title: "Status", Path: "Git", Details: "Shows the working tree status"},
+		{Title: "Prune", Path: "Docker", Details: "Removes unused data"},
+		{Title: "Log", Path: "Git", Details: "Shows the commit log"},
+	}
+	// Grouped by path: Git, Status, Log, Docker, Prune
+	rows := buildSearchRows(results, true, "Menu")
+
+	tests := []struct {
+		index   int
+		details string
+		ok      bool
+	}{
+		{0, "", false}, // the Git header
+		{1, "Shows the working tree status", true},
+		{2, "Shows the commit log", true},
+		{3, "", false},
+		{4, "Removes unused data", true},
+		{5, "", false},
+		{-1, "", false},
+	}
+	for _, tt := range tests {
+		option, ok := optionAt(rows, tt.index)
+		if ok != tt.ok || option.Details != tt.details {
+			t.Errorf("optionAt(%d) = %q, %t, want %q, %t", tt.index, option.Details, ok, tt.details, tt.ok)
+		}
+	}
+}
+
+func TestEmitCommandToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last")
+	if err := os.WriteFile(path, []byte("an older and much longer command"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emitCommand("cd '/tmp/my dir' && ls", path); err != nil {
+		t.Fatalf("emitCommand() failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file is truncated, not written over
+	if got := string(data); got != "cd '/tmp/my dir' && ls" {
+		t.Errorf("file contains %q, want only the command", got)
+	}
+
+	missing := filepath.Join(t.TempDir(), "no", "such", "dir", "last")
+	if err := emitCommand("ls", missing); err == nil {
+		t.Error("emitCommand() to a missing directory succeeded, want an error")
+	}
+}
+
+func TestSecretArgIsSubstitutedButNotKept(t *testing.T) {
+	option := Option{
+		Title:   "Login",
+		Command: "login --user ${1:user} --token ${2:token}",
+		Args:    []Arg{{Name: "user"}, {Name: "token", Secret: true}},
+	}
+	parameters := parseParameters(option.Command)
+	values := map[string]string{"${1:user}": "me", "${2:token}": "hunter2"}
+
+	command := fillParameters(option.Command, parameters, values)
+	if command != "login --user me --token hunter2" {
+		t.Errorf("fillParameters() = %q, want both values filled in", command)
+	}
+	if !hasSecretArgs(option) {
+		t.Error("hasSecretArgs() = false, want true")
+	}
+
+	// Stats are kept by the command as configured, without any values
+	stats := make(map[string]CommandStats)
+	if shouldRecord(option, command, nil) {
+		recordExecution(stats, option, time.Now())
+	}
+	for recorded := range stats {
+		if strings.Contains(recorded, "hunter2") {
+			t.Errorf("stats recorded %q", recorded)
+		}
+	}
+
+	// The last arguments keep the user, not the token
+	last := make(lastArgs)
+	last.remember("Login", option.Args, map[string]string{"user": "me", "token": "hunter2"})
+	if last["Login"]["user"] != "me" || last["Login"]["token"] != "" {
+		t.Errorf("last arguments = %v, want only the user", last["Login"])
+	}
+	if got := last.value("Login", option.Args[1]); got != "" {
+		t.Errorf("last token = %q, want it asked for again", got)
+	}
+}
+
+func TestDedupeSearchResults(t *testing.T) {
+	tree := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Prune", Command: "docker system prune"},
+			{Title: "Logs", Command: "docker logs -f"},
+		}},
+		{Title: "Cleanup", Children: []Option{
+			{Title: "Prune", Command: "docker system prune"},
+			// Same command under another title is a different entry
+			{Title: "Prune all", Command: "docker system prune"},
+		}},
+	}
+	results := newOptionIndex(flattenOptions(tree)).Search("prune", searchSettings{MinScore: defaultMinScore})
+	if len(results) != 3 {
+		t.Fatalf("Search() = %q, want both Prunes and Prune all", titles(results))
+	}
+
+	var paths []string
+	for _, opt := range dedupeOptions(results) {
+		paths = append(paths, joinPath(opt.Path, opt.Title))
+	}
+	// The first, best ranked, location is kept
+	if want := []string{"Cleanup/Prune", "Cleanup/Prune all"}; !slices.Equal(paths, want) {
+		t.Errorf("dedupeOptions() = %q, want %q", paths, want)
+	}
+}
+
+func TestDisabledOptionsAreSkippedAndNotRun(t *testing.T) {
+	options := []Option{
+		{Title: "Build", Command: "make"},
+		{Title: "Deploy", Command: "make deploy", Disabled: true, DisabledReason: "needs VPN"},
+		{Title: "Release", Command: "make release", Disabled: true},
+		{Title: "Test", Command: "make test", Hotkey: "t"},
+		{Title: "Lint", Command: "make lint", Disabled: true, Hotkey: "l"},
+	}
+	rows := buildMenuRows(options, false)
+
+	tests := []struct {
+		index int
+		step  int
+		want  int
+	}{
+		// Down from Build and Up from Test pass over both disabled options
+		{1, 1, 3},
+		{2, -1, 0},
+		// With nothing further down, navigation turns back
+		{4, 1, 3},
+		{0, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := selectableRow(rows, tt.index, tt.step); got != tt.want {
+			t.Errorf("selectableRow(%d, %d) = %d, want %d", tt.index, tt.step, got, tt.want)
+		}
+	}
+
+	for i, opt := range options {
+		if rows[i].Selectable() == opt.Disabled {
+			t.Errorf("%s: selectable = %t, want %t", opt.Title, rows[i].Selectable(), !opt.Disabled)
+		}
+		if isRunnable(opt) == opt.Disabled {
+			t.Errorf("%s: runnable = %t, want %t", opt.Title, isRunnable(opt), !opt.Disabled)
+		}
+	}
+	if hotkeys := menuHotkeys(options, nil); len(hotkeys) != 1 || hotkeys['t'] != 3 {
+		t.Errorf("menuHotkeys() = %v, want only t for Test", hotkeys)
+	}
+
+	// Search lands on disabled results so the infoBox can show why they can't run
+	rows = buildSearchRows(options[1:2], false, "")
+	if got := selectableRow(rows, 0, 1); got < 0 || rows[got].Option.Title != "Deploy" {
+		t.Errorf("selectableRow() in search results = %d, want the disabled Deploy", got)
+	}
+}
+
+func TestEmptyChildren(t *testing.T) {
+	var options []Option
+	err := json.Unmarshal([]byte(`[
+		{"title": "Logs", "command": "journalctl -f", "children": []},
+		{"title": "Later", "children": []}
+	]`), &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		option     Option
+		empty      bool
+		title      string
+		selectable bool
+	}{
+		// With a command it's a leaf that runs it
+		{options[0], false, "Logs", true},
+		// Without one it's shown as a category that can't be opened
+		{options[1], true, "[gray]> Later[-]", false},
+	}
+	rows := buildMenuRows(options, false)
+	for i, tt := range tests {
+		if got := isEmptyCategory(tt.option); got != tt.empty {
+			t.Errorf("isEmptyCategory(%s) = %t, want %t", tt.option.Title, got, tt.empty)
+		}
+		if got := displayTitle(tt.option); got != tt.title {
+			t.Errorf("displayTitle(%s) = %q, want %q", tt.option.Title, got, tt.title)
+		}
+		if got := rows[i].Selectable(); got != tt.selectable {
+			t.Errorf("%s: selectable = %t, want %t", tt.option.Title, got, tt.selectable)
+		}
+		if got := isRunnable(tt.option); got != tt.selectable {
+			t.Errorf("isRunnable(%s) = %t, want %t", tt.option.Title, got, tt.selectable)
+		}
+	}
+	// Only the leaf can be found by search
+	if got := titles(flattenOptions(options)); fmt.Sprint(got) != "[Logs]" {
+		t.Errorf("flattenOptions() = %q, want [Logs]", got)
+	}
+}
+
+func TestFinishMenu(t *testing.T) {
+	tests := []struct {
+		name     string
+		selected bool
+		command  string
+		exitCode int
+		want     string
+		wantCode int
+	}{
+		// A command picked earlier but not chosen must never reach the wrapper
+		{"quit", false, "", 0, "", abortExitCode},
+		{"quit after a prompt was cancelled", false, "rm -rf build", 3, "", abortExitCode},
+		{"selected", true, "cd ~/Desktop", 0, "cd ~/Desktop", 0},
+		{"selected with exit code", true, "cd ~/Desktop", 3, "cd ~/Desktop", 3},
+		{"ran in exec mode", true, "", 0, "", 0},
+	}
+	for _, tt := range tests {
+		var stream strings.Builder
+		code, err := finishMenu(tt.selected, tt.command, tt.exitCode, func(command string) error {
+			stream.WriteString(command)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if stream.String() != tt.want || code != tt.wantCode {
+			t.Errorf("%s: wrote %q and returned %d, want %q and %d", tt.name, stream.String(), code, tt.want, tt.wantCode)
+		}
+	}
+
+	failed := errors.New("disk full")
+	if code, err := finishMenu(true, "ls", 0, func(string) error { return failed }); code != 1 || err != failed {
+		t.Errorf("finishMenu() with a failing stream = %d, %v, want 1, %v", code, err, failed)
+	}
+}
+
+func TestSearchResultCap(t *testing.T) {
+	var results []Option
+	for i := range 5 {
+		results = append(results, Option{Title: fmt.Sprintf("Command %d", i), Command: "true"})
+	}
+
+	tests := []struct {
+		max        int
+		wantShown  int
+		wantHidden int
+	}{
+		{2, 2, 3},
+		{4, 4, 1},
+		{5, 5, 0},
+		{10, 5, 0},
+		{0, 5, 0},
+	}
+	for _, tt := range tests {
+		shown, hidden := limitResults(results, tt.max)
+		if len(shown) != tt.wantShown || hidden != tt.wantHidden {
+			t.Errorf("limitResults(%d) = %d shown, %d hidden, want %d and %d", tt.max, len(shown), hidden, tt.wantShown, tt.wantHidden)
+		}
+
+		rows := withMoreRow(buildSearchRows(shown, false, "Main Menu"), hidden, messages{})
+		if hidden == 0 {
+			if len(rows) != len(shown) {
+				t.Errorf("limitResults(%d): %d rows for %d results, want no more row", tt.max, len(rows), len(shown))
+			}
+			continue
+		}
+		more := rows[len(rows)-1]
+		if len(rows) != len(shown)+1 || more.Text != fmt.Sprintf("… (%d more, refine search)", hidden) {
+			t.Errorf("limitResults(%d): last of %d rows is %q, want the more row", tt.max, len(rows), more.Text)
+		}
+		// Navigation turns back at the more row and Enter has nothing to run there
+		if got := selectableRow(rows, len(rows)-1, 1); got != len(rows)-2 {
+			t.Errorf("limitResults(%d): selectableRow() from the more row = %d, want %d", tt.max, got, len(rows)-2)
+		}
+		if _, ok := optionAt(rows, len(rows)-1); ok {
+			t.Errorf("limitResults(%d): the more row has an option", tt.max)
+		}
+	}
+}
+
+func TestArgumentForm(t *testing.T) {
+	option := Option{
+		Title:   "Logs",
+		Command: "kubectl logs ${1:pod} -n ${2:namespace} --tail ${3:lines}",
+		Args: []Arg{
+			{Name: "pod", Required: true},
+			{Name: "namespace", Default: "default"},
+			{Name: "lines", Default: "100", Pattern: `\d+`},
+		},
+	}
+	parameters := parseParameters(option.Command)
+	// The form starts out with the defaults, nothing was entered before
+	var defaults []string
+	for _, param := range parameters {
+		arg, _ := argFor(option, param.Label)
+		defaults = append(defaults, lastArgs{}.value("Logs", arg))
+	}
+
+	tests := []struct {
+		name       string
+		entered    []string
+		wantFailed int
+		wantErr    string
+		want       string
+	}{
+		{"defaults only", defaults, 0, "pod is required", ""},
+		{"failing pattern", []string{"web", "default", "ten"}, 2, `lines must match \d+`, ""},
+		{"defaults substituted", []string{"web", defaults[1], defaults[2]}, 0, "", "kubectl logs web -n default --tail 100"},
+		{"optional left empty", []string{"web", "", "5"}, 0, "", "kubectl logs web -n  --tail 5"},
+	}
+	for _, tt := range tests {
+		values, byName, failed, err := argumentValues(option, parameters, tt.entered)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr || failed != tt.wantFailed {
+				t.Errorf("%s: argumentValues() = field %d, %v, want field %d, %s", tt.name, failed, err, tt.wantFailed, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := fillParameters(option.Command, parameters, values); got != tt.want {
+			t.Errorf("%s: command = %q, want %q", tt.name, got, tt.want)
+		}
+		if byName["pod"] != "web" {
+			t.Errorf("%s: values by name = %v, want pod remembered", tt.name, byName)
+		}
+	}
+
+	if err := validateArg(Arg{Name: "lines", Pattern: "("}, "5"); err == nil {
+		t.Errorf("validateArg() with an invalid pattern succeeded")
+	}
+}
+
+func TestLayoutAfterResize(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldHeight int
+		newHeight int
+		withInput bool
+		compact   bool
+		wantOld   []int
+		wantNew   []int
+	}{
+		{"shrunk", 40, 12, false, false, []int{0, 5}, []int{0, 3}},
+		{"shrunk while searching", 40, 12, true, false, []int{1, 0, 5}, []int{1, 0, 3}},
+		{"grown", 6, 50, false, false, []int{0, 1}, []int{0, 5}},
+		{"tiny", 24, 3, true, false, []int{1, 0, 5}, []int{1, 0, 1}},
+		{"compact", 40, 12, false, true, []int{0, 1}, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		for _, size := range []struct {
+			height int
+			want   []int
+		}{{tt.oldHeight, tt.wantOld}, {tt.newHeight, tt.wantNew}} {
+			got := layoutRows(tt.withInput, infoBoxHeight(size.height), tt.compact)
+			if !slices.Equal(got, size.want) {
+				t.Errorf("%s: rows at height %d = %v, want %v", tt.name, size.height, got, size.want)
+			}
+		}
+	}
+}
+
+func TestGroupedSearchRows(t *testing.T) {
+	// Ranked best first, groups appear in the order of their best result
+	results := []Option{
+		{Title: "Branch", Command: "git branch", Path: "Git"},
+		{Title: "Branches", Command: "git branch -a"},
+		{Title: "Delete branch", Command: "git branch -d", Path: "Git"},
+		{Title: "Branch prune", Command: "git remote prune", Path: "Git/Remote"},
+	}
+
+	var got []string
+	rows := buildSearchRows(results, true, "Main Menu")
+	for _, row := range rows {
+		if row.Option == nil {
+			got = append(got, "# "+row.Text)
+			continue
+		}
+		got = append(got, row.Text)
+	}
+	want := []string{"# Git", "Branch", "Delete branch", "# Main Menu", "Branches", "# Git/Remote", "Branch prune"}
+	if !slices.Equal(got, want) {
+		t.Errorf("buildSearchRows() = %q, want %q", got, want)
+	}
+
+	// Up and Down pass over the headers
+	if next := selectableRow(rows, 2, 1); next != 2 {
+		t.Errorf("selectableRow(2, 1) = %d, want 2", next)
+	}
+	if next := selectableRow(rows, 3, 1); next != 4 {
+		t.Errorf("selectableRow(3, 1) = %d, want 4 past the Main Menu header", next)
+	}
+	if next := selectableRow(rows, 3, -1); next != 2 {
+		t.Errorf("selectableRow(3, -1) = %d, want 2", next)
+	}
+	if first := selectableRow(rows, 0, 1); first != 1 {
+		t.Errorf("first selectable row = %d, want 1 below the first header", first)
+	}
+
+	if got := len(buildSearchRows(results, false, "Main Menu")); got != len(results) {
+		t.Errorf("ungrouped rows = %d, want one per result", got)
+	}
+}
+
+func TestCategoryCounts(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Ps", Command: "docker ps"},
+			{Title: "Containers", Separator: true},
+			{Title: "Compose", Children: []Option{
+				{Title: "Up", Command: "docker compose up"},
+				{Title: "Down", Command: "docker compose down"},
+			}},
+		}},
+		{Title: "Top", Command: "top"},
+	}
+
+	tests := []struct {
+		recursive bool
+		want      []string
+	}{
+		// Separators aren't items, a nested category counts as one
+		{false, []string{"> Docker [gray](2)[-]", "Top"}},
+		// Recursively only what can be run counts
+		{true, []string{"> Docker [gray](3)[-]", "Top"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, row := range buildMenuRows(options, tt.recursive) {
+			got = append(got, row.Text)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("buildMenuRows(recursive %t) = %q, want %q", tt.recursive, got, tt.want)
+		}
+	}
+}
+
+// TestSelectExitsWithOptionExitCode runs talias --select in a child process,
+// the test binary calling main, to see its real exit status
+func TestSelectExitsWithOptionExitCode(t *testing.T) {
+	if os.Getenv("TALIAS_TEST_MAIN") == "1" {
+		os.Args = []string{"talias", "--select", os.Getenv("TALIAS_TEST_SELECT")}
+		main()
+		os.Exit(0)
+	}
+
+	home := t.TempDir()
+	config := filepath.Join(home, "options.json")
+	err := os.WriteFile(config, []byte(`[
+		{"title": "Deploy", "command": "make deploy", "exitCode": 42},
+		{"title": "Test", "command": "make test"}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"Deploy", 42},
+		{"Test", 0},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSelectExitsWithOptionExitCode$")
+		cmd.Env = append(os.Environ(), "TALIAS_TEST_MAIN=1", "TALIAS_TEST_SELECT="+tt.path, "HOME="+home, "TALIAS_CONFIG="+config)
+		out, err := cmd.Output()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.wantCode {
+			t.Errorf("--select %s exited with %d, want %d", tt.path, code, tt.wantCode)
+		}
+		// The command is still printed for the wrapper to eval
+		if want := "make " + strings.ToLower(tt.path); string(out) != want {
+			t.Errorf("--select %s printed %q, want %q", tt.path, out, want)
+		}
+	}
+}
+
+func TestMenuHotkeys(t *testing.T) {
+	keys, err := resolveKeys(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []Option{
+		{Title: "Git", Hotkey: "g", Children: []Option{{Title: "Log", Command: "git log"}}},
+		{Title: "Quit the VPN", Hotkey: "q", Command: "vpn down"}, // q quits talias
+		{Title: "Find", Hotkey: "?", Command: "find ."},           // ? searches
+		{Title: "Grep", Hotkey: "g", Command: "grep"},             // g is Git's
+		{Title: "Deploy", Hotkey: "d", Command: "make deploy", Disabled: true},
+		{Title: "Top", Hotkey: "t", Command: "top"},
+		{Title: "Long", Hotkey: "ab", Command: "true"},
+	}
+
+	hotkeys := menuHotkeys(options, keys)
+	want := map[rune]int{'g': 0, 't': 5}
+	if len(hotkeys) != len(want) {
+		t.Errorf("menuHotkeys() = %q, want %q", hotkeys, want)
+	}
+	for key, index := range want {
+		if hotkeys[key] != index {
+			t.Errorf("hotkey %c = %d, want %d", key, hotkeys[key], index)
+		}
+	}
+
+	// Dispatched within the menu: the hotkey's item is selected and activated
+	list := tview.NewList()
+	var activated []string
+	for _, opt := range options {
+		list.AddItem(opt.Title, "", 0, func() { activated = append(activated, opt.Title) })
+	}
+	capture := hotkeyCapture(list, func() map[rune]int { return hotkeys })
+	for _, key := range []rune{'t', 'q', 'x', 'g'} {
+		if event := capture(tcell.NewEventKey(tcell.KeyRune, key, tcell.ModNone)); event != nil {
+			list.InputHandler()(event, func(tview.Primitive) {})
+		}
+	}
+	if len(activated) != 2 || activated[0] != "Top" || activated[1] != "Git" {
+		t.Errorf("activated %q, want Top then Git", activated)
+	}
+	if got := list.GetCurrentItem(); got != 0 {
+		t.Errorf("selected item = %d, want 0 (Git)", got)
+	}
+}
+
+func TestEditThenExecute(t *testing.T) {
+	var ran []string
+	runner := runnerFunc(func(ctx context.Context, command string) (int, error) {
+		ran = append(ran, command)
+		return 0, nil
+	})
+	option := Option{Title: "Containers", Command: "docker ps"}
+
+	tests := []struct {
+		text   string
+		values map[string]string // for placeholders left in the edit
+		want   []string
+	}{
+		{"docker ps -a  ", nil, []string{"docker ps -a"}},
+		{"docker logs ${1:container}", map[string]string{"${1:container}": "web"}, []string{"docker logs web"}},
+		// Clearing the command runs nothing
+		{"   ", nil, nil},
+	}
+	for _, tt := range tests {
+		ran = nil
+		edited, ok := editCommand(option, tt.text)
+		if ok {
+			command := fillParameters(edited.Command, parseParameters(edited.Command), tt.values)
+			runWithTimeout(runner, command, 0)
+		}
+		if !slices.Equal(ran, tt.want) {
+			t.Errorf("editing to %q ran %q, want %q", tt.text, ran, tt.want)
+		}
+		if edited.Title != option.Title {
+			t.Errorf("editing to %q changed the title to %q", tt.text, edited.Title)
+		}
+	}
+	if option.Command != "docker ps" {
+		t.Errorf("editing changed the option's own command to %q", option.Command)
+	}
+}
+
+func TestFilterCurrentMenu(t *testing.T) {
+	current := []Option{
+		{Title: "Compose", Children: []Option{
+			{Title: "Up", Command: "docker compose up"},
+			{Title: "Down", Command: "docker compose down"},
+		}},
+		{Title: "Containers", Command: "docker ps"},
+		{Title: "Images", Command: "docker images", Aliases: []string{"pictures"}},
+		{Title: "Prune", Command: "docker system prune"},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Menu order, not score order
+		{"co", []string{"Compose", "Containers"}},
+		{"pic", []string{"Images"}},
+		// Children of the menu's categories aren't searched
+		{"down", nil},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		got := filterOptions(current, tt.query, defaultMinScore)
+		if !slices.Equal(titles(got), tt.want) {
+			t.Errorf("filterOptions(%q) = %q, want %q", tt.query, titles(got), tt.want)
+		}
+	}
+
+	// A matching category still opens on its children
+	filtered := filterOptions(current, "compose", defaultMinScore)
+	rows := buildMenuRows(filtered, false)
+	if len(rows) != 1 || !rows[0].Selectable() || !slices.Equal(titles(rows[0].Option.Children), []string{"Up", "Down"}) {
+		t.Errorf("filtered Compose rows = %+v, want a selectable category with Up and Down", rows)
+	}
+	if len(current) != 4 {
+		t.Errorf("filtering changed the current menu to %q", titles(current))
+	}
+}
+
+func TestEmitPath(t *testing.T) {
+	options := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Branch", Children: []Option{{Title: "delete", Command: "git branch -d ${1:branch}"}}},
+		}},
+	}
+	option, ok := findOptionByPath(options, "Git/Branch/delete")
+	if !ok {
+		t.Fatal("nested option not found")
+	}
+
+	out, err := os.CreateTemp(t.TempDir(), "path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	emitPath(out, option)
+	// Options picked from the menu shown get the path of that menu
+	emitPath(out, Option{Title: "delete", Path: "Git/Branch"})
+	emitPath(nil, option)
+
+	got, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Git/Branch/delete\nGit/Branch/delete\n"; string(got) != want {
+		t.Errorf("emitPath() wrote %q, want %q", got, want)
+	}
+}
+
+func TestOpensOnEnter(t *testing.T) {
+	children := []Option{{Title: "Clean", Command: "make clean"}}
+	tests := []struct {
+		name   string
+		option Option
+		want   bool
+	}{
+		{"leaf", Option{Title: "Build", Command: "make"}, false},
+		{"category", Option{Title: "Build", Children: children}, true},
+		{"category with a command", Option{Title: "Build", Command: "make", Children: children}, true},
+		{"forced leaf", Option{Title: "Build", Command: "make", Run: true, Children: children}, false},
+		{"forced leaf without a command", Option{Title: "Build", Run: true, Children: children}, true},
+		{"children command", Option{Title: "Branches", ChildrenCmd: "git branch"}, true},
+		{"empty children with a command", Option{Title: "Build", Command: "make", Children: []Option{}}, false},
+	}
+	for _, tt := range tests {
+		if got := opensOnEnter(tt.option); got != tt.want {
+			t.Errorf("opensOnEnter(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Search offers the forced leaf itself as well as its children
+	options := []Option{{Title: "Build", Command: "make", Run: true, Children: children}}
+	if got := titles(flattenOptions(options)); !slices.Equal(got, []string{"Build", "Clean"}) {
+		t.Errorf("flattenOptions() = %q, want Build and Clean", got)
+	}
+}
+
+func TestBreadcrumb(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Containers", Children: []Option{{Title: "restart", Command: "docker restart ${1:name}"}}},
+		}},
+	}
+	searched, ok := findOptionByPath(options, "Docker/Containers/restart")
+	if !ok {
+		t.Fatal("nested option not found")
+	}
+
+	tests := []struct {
+		name     string
+		option   Option
+		menuPath string
+		want     string
+	}{
+		{"menu shown", options[0].Children[0].Children[0], "Docker/Containers", "Docker/Containers/restart"},
+		{"top level", options[0], "", "Docker"},
+		// Search results carry their own path, main passes no menu path for them
+		{"search result", searched, "", "Docker/Containers/restart"},
+		// Options from other menus, like most used ones, keep their own path
+		{"own path in another menu", searched, "Most used", "Docker/Containers/restart"},
+	}
+	for _, tt := range tests {
+		if got := breadcrumb(tt.option, tt.menuPath); got != tt.want {
+			t.Errorf("breadcrumb(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := parentPath("Docker/Containers/restart"); got != "Docker/Containers" {
+		t.Errorf("parentPath() = %q, want Docker/Containers", got)
+	}
+}
+
+func TestSeparatorsAreSkipped(t *testing.T) {
+	options := []Option{
+		{Title: "— Build —", Separator: true},
+		{Title: "Build", Command: "make"},
+		{Title: "— Deployment —", Separator: true},
+		{Title: "Deploy", Command: "make deploy"},
+		{Title: "Release", Children: []Option{
+			{Title: "— Steps —", Separator: true},
+			{Title: "Tag", Command: "git tag"},
+		}},
+	}
+	rows := buildMenuRows(options, false)
+	if rows[0].Selectable() || rows[2].Selectable() {
+		t.Errorf("separators are selectable")
+	}
+	if got := displayTitle(options[2]); got != "[gray::b]— Deployment —[-::-]" {
+		t.Errorf("displayTitle(separator) = %q, want a bold gray heading", got)
+	}
+
+	tests := []struct {
+		index int
+		step  int
+		want  int
+	}{
+		// The menu opens past the heading at the top
+		{0, 1, 1},
+		{2, 1, 3},
+		{2, -1, 1},
+		// Nothing selectable above the first heading, navigation turns back
+		{0, -1, 1},
+	}
+	for _, tt := range tests {
+		if got := selectableRow(rows, tt.index, tt.step); got != tt.want {
+			t.Errorf("selectableRow(%d, %d) = %d, want %d", tt.index, tt.step, got, tt.want)
+		}
+	}
+
+	if got := titles(flattenOptions(options)); !slices.Equal(got, []string{"Build", "Deploy", "Tag"}) {
+		t.Errorf("flattenOptions() = %q, want no separators", got)
+	}
+	search := newOptionIndex(flattenOptions(options)).Search("deploy", searchSettings{MinScore: defaultMinScore})
+	if got := titles(search); !slices.Equal(got, []string{"Deploy"}) {
+		t.Errorf("Search(deploy) = %q, want only Deploy", got)
+	}
+}
+
+func TestBeforeDraw(t *testing.T) {
+	tests := []struct {
+		name              string
+		height            int
+		lastHeight        int
+		respectBackground bool
+		wantRelayout      bool
+		wantClear         bool
+	}{
+		{"first frame", 40, 0, true, true, true},
+		{"same size", 40, 40, true, false, true},
+		{"resized", 30, 40, true, true, true},
+		{"solid background", 40, 40, false, false, false},
+		{"solid background resized", 30, 40, false, true, false},
+	}
+	for _, tt := range tests {
+		relayout, clear := beforeDraw(tt.height, tt.lastHeight, tt.respectBackground)
+		if relayout != tt.wantRelayout || clear != tt.wantClear {
+			t.Errorf("beforeDraw(%s) = %t, %t, want %t, %t", tt.name, relayout, clear, tt.wantRelayout, tt.wantClear)
+		}
+	}
+}
+
+func TestDefaultChildIndex(t *testing.T) {
+	options := []Option{
+		{Title: "— Common —", Separator: true},
+		{Title: "Status", Command: "git status"},
+		{Title: "Push", Command: "git push", Disabled: true},
+		{Title: "Pull", Command: "git pull"},
+	}
+	rows := buildMenuRows(options, false)
+	tests := []struct {
+		title  string
+		want   int
+		wantOK bool
+	}{
+		{"Pull", 3, true},
+		{"Status", 1, true},
+		// Rows that can't be selected fall back like unknown titles do
+		{"Push", 0, false},
+		{"— Common —", 0, false},
+		{"Fetch", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := defaultChildIndex(rows, tt.title)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("defaultChildIndex(%q) = %d, %t, want %d, %t", tt.title, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPrefilledSearch(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Docker Up", Command: "docker compose up"},
+			{Title: "Docker Down", Command: "docker compose down"},
+		}},
+		{Title: "Kubernetes", Children: []Option{{Title: "Pods", Command: "kubectl get pods"}}},
+	}
+	index := newOptionIndex(flattenOptions(options))
+	settings := searchSettings{MinScore: defaultMinScore}
+
+	tests := []struct {
+		query        string
+		selectSingle bool
+		wantResults  []string
+		wantPicked   string
+	}{
+		{"docker", true, []string{"Docker Up", "Docker Down"}, ""},
+		{"pods", false, []string{"Pods"}, ""},
+		{"pods", true, []string{"Pods"}, "Pods"},
+		{"zzz", true, nil, ""},
+	}
+	for _, tt := range tests {
+		results := index.Search(tt.query, settings)
+		if got := titles(results); !slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(tt.wantResults))) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.wantResults)
+		}
+		picked, ok := singleResult(results, tt.selectSingle)
+		if ok != (tt.wantPicked != "") || picked.Title != tt.wantPicked {
+			t.Errorf("singleResult(%q, %t) = %q, %t, want %q", tt.query, tt.selectSingle, picked.Title, ok, tt.wantPicked)
+		}
+	}
+}
+
+func TestNoMatchesRow(t *testing.T) {
+	index := newOptionIndex(flattenOptions([]Option{{Title: "Build", Command: "make"}}))
+	results := index.Search("zzz[x]", searchSettings{MinScore: defaultMinScore})
+	rows := searchResultRows(results, 0, false, "zzz[x]", messages{})
+	if len(rows) != 1 || rows[0].Text != "No matches for 'zzz[x[]'" {
+		t.Fatalf("rows = %+v, want a single escaped no matches row", rows)
+	}
+	// Enter has nothing to run
+	if rows[0].Selectable() {
+		t.Errorf("the no matches row is selectable")
+	}
+	if _, ok := optionAt(rows, 0); ok {
+		t.Errorf("optionAt() found an option on the no matches row")
+	}
+
+	// An empty query lists nothing without the row
+	if rows := searchResultRows(nil, 0, false, "", messages{}); len(rows) != 0 {
+		t.Errorf("rows for an empty query = %+v, want none", rows)
+	}
+	if rows := searchResultRows(index.Search("build", searchSettings{MinScore: defaultMinScore}), 0, false, "build", messages{}); len(rows) != 1 || !rows[0].Selectable() {
+		t.Errorf("rows for a match = %+v, want the result", rows)
+	}
+}
+
+func TestPhraseForm(t *testing.T) {
+	var answers []bool
+	form := phraseForm("prod-eu", messages{}, func(confirmed bool) { answers = append(answers, confirmed) })
+	input := form.GetFormItem(0).(*tview.InputField)
+	run := form.GetButton(0)
+	press := func(button *tview.Button) {
+		button.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(tview.Primitive) {})
+	}
+
+	tests := []struct {
+		typed   string
+		enabled bool
+	}{
+		{"", false},
+		{"prod", false},
+		{"PROD-EU", false},
+		{"prod-eu ", false},
+		{"prod-eu", true},
+	}
+	for _, tt := range tests {
+		input.SetText(tt.typed)
+		if run.IsDisabled() == tt.enabled {
+			t.Errorf("typed %q: Run enabled = %t, want %t", tt.typed, !run.IsDisabled(), tt.enabled)
+		}
+	}
+
+	// Run does nothing until the phrase matches
+	input.SetText("prod")
+	press(run)
+	if len(answers) != 0 {
+		t.Fatalf("Run with the wrong phrase answered %v", answers)
+	}
+	input.SetText("prod-eu")
+	press(run)
+	press(form.GetButton(1))
+	if !slices.Equal(answers, []bool{true, false}) {
+		t.Errorf("answers = %v, want Run then Cancel", answers)
+	}
+}
+
+// TestQuietHidesWarnings runs talias in a child process with a config that
+// has a duplicate title, which is normally warned about on stderr
+func TestQuietHidesWarnings(t *testing.T) {
+	if os.Getenv("TALIAS_TEST_MAIN") == "1" {
+		os.Args = append([]string{"talias"}, strings.Fields(os.Getenv("TALIAS_TEST_ARGS"))...)
+		main()
+		os.Exit(0)
+	}
+
+	home := t.TempDir()
+	config := filepath.Join(home, "options.json")
+	err := os.WriteFile(config, []byte(`[
+		{"title": "Deploy", "command": "make deploy"},
+		{"title": "Deploy", "command": "make deploy-staging"}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args       string
+		wantStderr bool
+	}{
+		{"--select Deploy", true},
+		{"--quiet --select Deploy", false},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestQuietHidesWarnings$")
+		cmd.Env = append(os.Environ(), "TALIAS_TEST_MAIN=1", "TALIAS_TEST_ARGS="+tt.args, "HOME="+home, "TALIAS_CONFIG="+config)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("talias %s: %v, stderr %q", tt.args, err, stderr.String())
+		}
+		if got := stderr.Len() > 0; got != tt.wantStderr {
+			t.Errorf("talias %s wrote %q to stderr, want output: %t", tt.args, stderr.String(), tt.wantStderr)
+		}
+		if string(out) != "make deploy" {
+			t.Errorf("talias %s printed %q, want the command", tt.args, out)
+		}
+	}
+}
+
+func TestCompactInfo(t *testing.T) {
+	// Toggled back and forth, the info box shrinks to one row and comes back
+	// at the height the terminal allows, with or without the input row
+	tests := []struct {
+		name        string
+		height      int
+		withInput   bool
+		wantFull    []int
+		wantCompact []int
+	}{
+		{"menu", 40, false, []int{0, 5}, []int{0, 1}},
+		{"search", 40, true, []int{1, 0, 5}, []int{1, 0, 1}},
+		{"small terminal", 12, false, []int{0, 3}, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		compact := false
+		for _, want := range [][]int{tt.wantFull, tt.wantCompact, tt.wantFull} {
+			got := layoutRows(tt.withInput, infoBoxHeight(tt.height), compact)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s: rows with compact %t = %v, want %v", tt.name, compact, got, want)
+			}
+			compact = !compact
+		}
+	}
+
+	details := "Stops the containers\nand removes them"
+	if got := compactDetails(Option{Command: "docker compose down"}, details, "docker compose down [x]"); got != "[gray]$[-] docker compose down [x[]" {
+		t.Errorf("compactDetails() of a command = %q, want the command as it runs", got)
+	}
+	if got := compactDetails(Option{Title: "Docker"}, "\n"+details, ""); got != "Stops the containers" {
+		t.Errorf("compactDetails() of a category = %q, want the first line of its details", got)
+	}
+}
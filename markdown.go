@@ -0,0 +1,56 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	markdownBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	markdownCode    = regexp.MustCompile("`([^`]+)`")
+	markdownBold    = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownItalic  = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+)
+
+// markdownToTview converts headings, bullet lists, bold, italics and inline
+// code to tview style tags, anything else is left as is
+func markdownToTview(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			lines[i] = "[::b]" + markdownInline(match[1]) + "[::-]"
+		} else if match := markdownBullet.FindStringSubmatch(line); match != nil {
+			lines[i] = match[1] + "• " + markdownInline(match[2])
+		} else {
+			lines[i] = markdownInline(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownInline converts emphasis and code spans within a line, text inside
+// code spans is not touched
+func markdownInline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range markdownCode.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(markdownEmphasis(line[last:loc[0]]))
+		b.WriteString("[yellow]" + line[loc[2]:loc[3]] + "[-]")
+		last = loc[1]
+	}
+	b.WriteString(markdownEmphasis(line[last:]))
+	return b.String()
+}
+
+// markdownEmphasis converts **bold**, __bold__, *italic* and _italic_
+func markdownEmphasis(text string) string {
+	text = markdownBold.ReplaceAllStringFunc(text, func(match string) string {
+		groups := markdownBold.FindStringSubmatch(match)
+		return "[::b]" + groups[1] + groups[2] + "[::-]"
+	})
+	return markdownItalic.ReplaceAllStringFunc(text, func(match string) string {
+		groups := markdownItalic.FindStringSubmatch(match)
+		return "[::i]" + groups[1] + groups[2] + "[::-]"
+	})
+}
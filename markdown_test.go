@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestMarkdownToTview(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{"# Deploy", "[::b]Deploy[::-]"},
+		{"### Steps to `run`", "[::b]Steps to [yellow]run[-][::-]"},
+		{"Runs **all** the tests", "Runs [::b]all[::-] the tests"},
+		{"Runs __all__ the tests", "Runs [::b]all[::-] the tests"},
+		{"Runs *only* the *fast* ones", "Runs [::i]only[::-] the [::i]fast[::-] ones"},
+		{"Runs _only_ these", "Runs [::i]only[::-] these"},
+		{"Call `make *all*` first", "Call [yellow]make *all*[-] first"},
+		{"- build\n* test\n  + lint **strict**", "• build\n• test\n  • lint [::b]strict[::-]"},
+		// Plain details are left alone
+		{"Lists running containers", "Lists running containers"},
+		{"snake_case_name and 2 * 3 * 4", "snake_case_name and 2 * 3 * 4"},
+		{"a\n\nb", "a\n\nb"},
+	}
+	for _, tt := range tests {
+		if got := markdownToTview(tt.markdown); got != tt.want {
+			t.Errorf("markdownToTview(%q) = %q, want %q", tt.markdown, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "github.com/rivo/tview"
+
+// markedList adds options to a tview.List and moves the selection marker of
+// the "arrow" and "both" selection styles along with the selection. It keeps
+// each item's text without the marker to put it back.
+type markedList struct {
+	list   *tview.List
+	style  string
+	texts  []string
+	marked int
+}
+
+func newMarkedList(list *tview.List, style string) *markedList {
+	return &markedList{list: list, style: style, marked: -1}
+}
+
+// Clear removes all items
+func (l *markedList) Clear() {
+	l.list.Clear()
+	l.texts = nil
+	l.marked = -1
+}
+
+// Add appends an item with text and secondary as the gray line below it
+func (l *markedList) Add(text string, secondary string, selected func()) {
+	l.texts = append(l.texts, text)
+	if secondary != "" {
+		secondary = selectionText(secondary, false, l.style)
+	}
+	l.list.AddItem(selectionText(text, false, l.style), secondary, 0, selected)
+}
+
+// Mark moves the marker to the item at index
+func (l *markedList) Mark(index int) {
+	if l.style == selectionHighlight {
+		return
+	}
+	for _, i := range []int{l.marked, index} {
+		if i >= 0 && i < len(l.texts) {
+			_, secondary := l.list.GetItemText(i)
+			l.list.SetItemText(i, selectionText(l.texts[i], i == index, l.style), secondary)
+		}
+	}
+	l.marked = index
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestMarkedListShowsSubtitles(t *testing.T) {
+	options := []Option{
+		{Title: "Desktop", Command: "cd ~/Desktop", Subtitle: "go to desktop"},
+		{Title: "Top", Command: "top"},
+		{Title: "Docker", Subtitle: "containers", Children: []Option{{Title: "ps", Command: "docker ps"}}},
+	}
+	for _, style := range []string{selectionHighlight, selectionArrow} {
+		list := tview.NewList()
+		items := newMarkedList(list, style)
+		for _, opt := range options {
+			items.Add(opt.Title, secondaryText(opt, false, nil), nil)
+		}
+		items.Mark(0)
+		items.Mark(2)
+
+		for i, opt := range options {
+			main, secondary := list.GetItemText(i)
+			want := selectionText(opt.Subtitle, false, style)
+			if opt.Subtitle == "" {
+				want = ""
+			}
+			if secondary != want {
+				t.Errorf("%s: secondary text of %s = %q, want %q", style, opt.Title, secondary, want)
+			}
+			if wantMain := selectionText(opt.Title, style != selectionHighlight && i == 2, style); main != wantMain {
+				t.Errorf("%s: text of %s = %q, want %q", style, opt.Title, main, wantMain)
+			}
+		}
+	}
+}
+
+func TestSecondaryText(t *testing.T) {
+	expand := func(command string) string { return "/home/me" + command[1:] }
+	long := Option{Title: "Long", Command: "~/bin/deploy --with [brackets] " + strings.Repeat("é", commandPreviewLength)}
+	cut := []rune(expand(long.Command))[:commandPreviewLength-1]
+	tests := []struct {
+		option       Option
+		showCommands bool
+		want         string
+	}{
+		{Option{Title: "Desktop", Command: "cd ~/Desktop", Subtitle: "go to desktop"}, false, "go to desktop"},
+		{Option{Title: "Top", Command: "top"}, false, ""},
+		// --show-commands shows the command as it will run, and a category's size
+		{Option{Title: "Desktop", Command: "~/Desktop", Subtitle: "go to desktop"}, true, "/home/me/Desktop"},
+		{Option{Title: "Docker", Children: []Option{{Title: "ps"}}}, true, "1 item"},
+		{Option{Title: "Docker", Children: []Option{{Title: "ps"}, {Title: "logs"}}}, true, "2 items"},
+		{Option{Title: "Notes", Subtitle: "nothing to run"}, true, "nothing to run"},
+		// Long commands are cut by runes, not bytes, and escaped for tview
+		{long, true, tview.Escape(string(cut) + "…")},
+	}
+	for _, tt := range tests {
+		if got := secondaryText(tt.option, tt.showCommands, expand); got != tt.want {
+			t.Errorf("secondaryText(%s, %t) = %q, want %q", tt.option.Title, tt.showCommands, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultMessages are the built-in English UI strings, translations in
+// ~/.talias/lang/<code>.json override them key by key
+var defaultMessages = map[string]string{
+	"mainMenu":      "Main Menu",
+	"welcome":       "Welcome! Select an option.",
+	"searchLabel":   "Search: ",
+	"searchMode":    "Search mode - type to filter options",
+	"selectFrom":    "Select an option from %s",
+	"moreResults":   "… (%d more, refine search)",
+	"noMatches":     "No matches for '%s'",
+	"noMatchesHint": "Nothing matched, try fewer or shorter terms",
+	"nothingToCopy": "Nothing to copy",
+	"copyFailed":    "Copy failed: %v",
+	"copied":        "Copied details of %s to clipboard",
+	"copiedPath":    "Copied %s to clipboard",
+	"disabled":      "Disabled: %s",
+	"emptyCategory": "Empty category",
+	"noCommand":     "(no command)",
+	"fillArguments": "Fill in the arguments for %s, Escape to cancel",
+	"run":           "Run",
+	"cancel":        "Cancel",
+	"confirmRun":    "Run %s?\n\n%s",
+	"typePhrase":    "Type %s to confirm ",
+	"runAgain":      "Enter again to run, Escape to cancel:",
+	"finished":      "%s finished",
+	"exitStatus":    "%s exited with status %d",
+	"runTimedOut":   "%s timed out and was stopped (status %d)",
+	"nothingRun":    "Nothing has been run yet",
+	"loading":       "Loading %s",
+	"loadDetails":   "Loading details",
+	"timedOut":      "[details timed out]",
+	"filterLabel":   "Filter: ",
+	"filterMode":    "Filtering %s - type to narrow it down",
+	"truncated":     "(truncated, Tab to show all)",
+	"editLabel":     "Command: ",
+	"editCommand":   "Edit the command, Enter to run it, Escape to cancel",
+	"openRight":     "Right to open %s",
+	"gotoLabel":     "Go to: ",
+	"edited":        "Edited %s",
+	"gotoMode":      "Type a menu path like Docker/Docker Down, Enter to go there",
+	"marked":        "%d marked, Enter runs them, Space again unmarks",
+	"cantMark":      "%s can't run together with others",
+	"confirmBatch":  "Run these %d commands?",
+}
+
+// messages is a catalog of UI strings by key
+type messages map[string]string
+
+// get looks up key, falling back to English, and formats it with args
+func (m messages) get(key string, args ...any) string {
+	text, ok := m[key]
+	if !ok {
+		text = defaultMessages[key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// languageCode picks the UI language from TALIAS_LANG or else LANG, e.g.
+// "de_DE.UTF-8" becomes "de_DE", and the C locale means English
+func languageCode(taliasLang string, lang string) string {
+	code := taliasLang
+	if code == "" {
+		code = lang
+	}
+	code, _, _ = strings.Cut(code, ".")
+	code, _, _ = strings.Cut(code, "@")
+	if code == "" || code == "C" || code == "POSIX" {
+		return "en"
+	}
+	return code
+}
+
+// loadMessages reads the catalog for code from dir, trying "de_DE.json" then
+// "de.json", no catalog means English
+func loadMessages(dir string, code string) (messages, error) {
+	candidates := []string{code}
+	if language, _, found := strings.Cut(code, "_"); found {
+		candidates = append(candidates, language)
+	}
+
+	for _, candidate := range candidates {
+		filename := filepath.Join(dir, candidate+".json")
+		data, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+		}
+
+		catalog := make(messages)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+		}
+		return catalog, nil
+	}
+	return messages{}, nil
+}
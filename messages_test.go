@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLanguageCode(t *testing.T) {
+	tests := []struct {
+		taliasLang string
+		lang       string
+		want       string
+	}{
+		{"", "de_DE.UTF-8", "de_DE"},
+		{"fr", "de_DE.UTF-8", "fr"},
+		{"", "sr_RS@latin", "sr_RS"},
+		{"", "C", "en"},
+		{"", "POSIX", "en"},
+		{"", "", "en"},
+	}
+	for _, tt := range tests {
+		if got := languageCode(tt.taliasLang, tt.lang); got != tt.want {
+			t.Errorf("languageCode(%q, %q) = %q, want %q", tt.taliasLang, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMessages(t *testing.T) {
+	dir := t.TempDir()
+	catalog := `{"mainMenu": "Hauptmenü", "moreResults": "… (%d weitere, Suche eingrenzen)"}`
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "xx.json"), []byte(`{"mainMenu": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// de_DE falls back to the language's catalog
+	msg, err := loadMessages(dir, "de_DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		args []any
+		want string
+	}{
+		{"mainMenu", nil, "Hauptmenü"},
+		{"moreResults", []any{3}, "… (3 weitere, Suche eingrenzen)"},
+		// Keys the catalog leaves out are English
+		{"welcome", nil, "Welcome! Select an option."},
+		{"selectFrom", []any{"Docker"}, "Select an option from Docker"},
+	}
+	for _, tt := range tests {
+		if got := msg.get(tt.key, tt.args...); got != tt.want {
+			t.Errorf("get(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	english, err := loadMessages(dir, "ja_JP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := english.get("mainMenu"); got != "Main Menu" {
+		t.Errorf("get(mainMenu) without a catalog = %q, want Main Menu", got)
+	}
+	if _, err := loadMessages(dir, "xx"); err == nil {
+		t.Errorf("loadMessages() of a broken catalog succeeded")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+// menuVisit is a menu as it was shown, for moving back and forward through visits
+type menuVisit struct {
+	Options  []Option
+	Stack    [][]Option
+	Title    string
+	Path     string
+	Selected int
+}
+
+// rootVisit is the main menu with no menus stacked above it
+func rootVisit(root []Option, title string) menuVisit {
+	return menuVisit{Options: root, Title: title}
+}
+
+// navHistory is a browser-like list of visited menus with a cursor at the one shown
+type navHistory struct {
+	visits []menuVisit
+	cursor int
+}
+
+// Push records a newly visited menu, dropping any visits forward of the cursor
+func (h *navHistory) Push(visit menuVisit) {
+	if len(h.visits) > 0 {
+		h.visits = h.visits[:h.cursor+1]
+	}
+	h.visits = append(h.visits, visit)
+	h.cursor = len(h.visits) - 1
+}
+
+// SetSelected remembers the selected item of the menu at the cursor
+func (h *navHistory) SetSelected(selected int) {
+	if len(h.visits) > 0 {
+		h.visits[h.cursor].Selected = selected
+	}
+}
+
+// Back moves the cursor to the previous visit
+func (h *navHistory) Back() (menuVisit, bool) {
+	if h.cursor == 0 || len(h.visits) == 0 {
+		return menuVisit{}, false
+	}
+	h.cursor--
+	return h.visits[h.cursor], true
+}
+
+// Forward moves the cursor to the next visit
+func (h *navHistory) Forward() (menuVisit, bool) {
+	if h.cursor >= len(h.visits)-1 {
+		return menuVisit{}, false
+	}
+	h.cursor++
+	return h.visits[h.cursor], true
+}
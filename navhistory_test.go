@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestGoToRootFromDeepMenu(t *testing.T) {
+	root := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Branch", Children: []Option{{Title: "Delete", Command: "git branch -d"}}},
+		}},
+		{Title: "Top", Command: "top"},
+	}
+	deep, _, err := resolveMenuPath(root, "Git/Branch", "Main Menu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history navHistory
+	history.Push(rootVisit(root, "Main Menu"))
+	history.Push(deep)
+
+	home := rootVisit(root, "Main Menu")
+	if len(home.Stack) != 0 || home.Path != "" || home.Title != "Main Menu" {
+		t.Errorf("root visit = %+v, want no stack or path", home)
+	}
+	if len(home.Options) != len(root) || home.Options[0].Title != "Git" {
+		t.Errorf("root visit shows %q, want the root options", titles(home.Options))
+	}
+
+	// Going home is a visit of its own, Back leads to the deep menu again
+	history.Push(home)
+	if back, ok := history.Back(); !ok || back.Path != "Git/Branch" || len(back.Stack) != 2 {
+		t.Errorf("Back() = %+v, %t, want Git/Branch with two menus above it", back, ok)
+	}
+}
+
+func TestNavHistoryCursor(t *testing.T) {
+	var history navHistory
+	if _, ok := history.Back(); ok {
+		t.Error("Back() on an empty history succeeded")
+	}
+	if _, ok := history.Forward(); ok {
+		t.Error("Forward() on an empty history succeeded")
+	}
+
+	history.Push(menuVisit{Title: "Main"})
+	history.Push(menuVisit{Title: "Git"})
+	history.SetSelected(3)
+	history.Push(menuVisit{Title: "Branch"})
+
+	steps := []struct {
+		move  func() (menuVisit, bool)
+		title string
+		ok    bool
+	}{
+		{history.Forward, "", false}, // already at the newest visit
+		{history.Back, "Git", true},
+		{history.Back, "Main", true},
+		{history.Back, "", false},
+		{history.Forward, "Git", true},
+	}
+	for i, step := range steps {
+		visit, ok := step.move()
+		if ok != step.ok || visit.Title != step.title {
+			t.Errorf("step %d = %q, %t, want %q, %t", i, visit.Title, ok, step.title, step.ok)
+		}
+	}
+	if visit, _ := history.Back(); visit.Selected != 0 {
+		t.Errorf("Main selected %d, want 0", visit.Selected)
+	}
+	if visit, _ := history.Forward(); visit.Selected != 3 {
+		t.Errorf("Git selected %d, want the remembered 3", visit.Selected)
+	}
+
+	// Navigating somewhere new from Git drops Branch ahead of it
+	history.Push(menuVisit{Title: "Docker"})
+	if _, ok := history.Forward(); ok {
+		t.Error("Forward() after a new visit succeeded, want the forward history dropped")
+	}
+	var back []string
+	for visit, ok := history.Back(); ok; visit, ok = history.Back() {
+		back = append(back, visit.Title)
+	}
+	if len(back) != 2 || back[0] != "Git" || back[1] != "Main" {
+		t.Errorf("going back visits %q, want [Git Main]", back)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// indexedText is an option's text lowercased once when the index is built
+// instead of on every keystroke
+type indexedText struct {
+	title       string
+	titleLength int // in runes
+	aliases     []string
+	path        string // breadcrumb path including the title
+	pathDetails string // path followed by the details
+}
+
+// optionIndex is the flattened options prepared for fuzzy search. Search
+// reuses its buffers, so its results are only valid until the next search.
+type optionIndex struct {
+	options []Option
+	texts   []indexedText
+	scored  []scoredOption
+	results []Option
+}
+
+// scoredOption is a match by its position in the index
+type scoredOption struct {
+	index int
+	score int
+}
+
+func newOptionIndex(options []Option) *optionIndex {
+	texts := make([]indexedText, len(options))
+	for i, opt := range options {
+		text := indexedText{
+			title:       strings.ToLower(opt.Title),
+			titleLength: utf8.RuneCountInString(opt.Title),
+			path:        strings.ToLower(joinPath(opt.Path, opt.Title)),
+		}
+		text.pathDetails = text.path + " " + strings.ToLower(opt.Details)
+		for _, alias := range opt.Aliases {
+			text.aliases = append(text.aliases, strings.ToLower(alias))
+		}
+		texts[i] = text
+	}
+	return &optionIndex{options: options, texts: texts}
+}
+
+// Search returns the options matching query, best first
+func (x *optionIndex) Search(query string, settings searchSettings) []Option {
+	if query == "" {
+		return x.options
+	}
+
+	queryLower := strings.ToLower(query)
+	tokens := strings.Fields(queryLower)
+	threshold := max(effectiveMinScore(queryLower, settings.MinScore), 1)
+
+	x.scored = x.scored[:0]
+	for i := range x.texts {
+		text := &x.texts[i]
+
+		// Aliases score like the title, the best match counts
+		score := fuzzyScore(queryLower, text.title)
+		for _, alias := range text.aliases {
+			score = max(score, fuzzyScore(queryLower, alias))
+		}
+		haystack := text.path
+		if settings.MatchDetails {
+			haystack = text.pathDetails
+		}
+		score = max(score, breadcrumbScore(tokens, haystack))
+		if score >= threshold {
+			x.scored = append(x.scored, scoredOption{index: i, score: score})
+		}
+	}
+
+	// Best matches first, equal scores go by rankedBefore so results don't
+	// jump around when the config is reloaded or merged in another order
+	slices.SortStableFunc(x.scored, func(a, b scoredOption) int {
+		if a.score != b.score {
+			return cmp.Compare(b.score, a.score)
+		}
+		if x.rankedBefore(a.index, b.index) {
+			return -1
+		}
+		if x.rankedBefore(b.index, a.index) {
+			return 1
+		}
+		return 0
+	})
+
+	x.results = x.results[:0]
+	for _, s := range x.scored {
+		x.results = append(x.results, x.options[s.index])
+	}
+	return x.results
+}
+
+// firstMatch is the best search result for query among options and
+// everything below them, what --first picks
+func firstMatch(options []Option, query string, settings searchSettings) (Option, bool) {
+	if strings.TrimSpace(query) == "" {
+		return Option{}, false
+	}
+	results := newOptionIndex(flattenOptions(options)).Search(query, settings)
+	if len(results) == 0 {
+		return Option{}, false
+	}
+	return results[0], true
+}
+
+// breadcrumbScore matches each query token against haystack, the option's
+// full breadcrumb path (and details when asked), so a query like "git branch
+// del" can span the hierarchy. Every token has to match.
+func breadcrumbScore(tokens []string, haystack string) int {
+	if len(tokens) == 0 {
+		return 0
+	}
+	total := 0
+	for _, token := range tokens {
+		score := fuzzyScore(token, haystack)
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	// Spread out matches rank below direct title matches
+	return total / len(tokens) * 4 / 5
+}
+
+// rankedBefore breaks ties between equally scoring options i and j: shorter
+// titles first, then alphabetically, then by path
+func (x *optionIndex) rankedBefore(i int, j int) bool {
+	a, b := &x.texts[i], &x.texts[j]
+	if a.titleLength != b.titleLength {
+		return a.titleLength < b.titleLength
+	}
+	if a.title != b.title {
+		return a.title < b.title
+	}
+	optA, optB := &x.options[i], &x.options[j]
+	if optA.Title != optB.Title {
+		return optA.Title < optB.Title
+	}
+	return optA.Path < optB.Path
+}
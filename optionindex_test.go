@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func titles(options []Option) []string {
+	var result []string
+	for _, opt := range options {
+		result = append(result, opt.Title)
+	}
+	return result
+}
+
+func TestSearchRanking(t *testing.T) {
+	index := newOptionIndex(flattenOptions([]Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Branch", Command: "git branch"},
+			{Title: "Delete branch", Command: "git branch -d"},
+			{Title: "Status", Command: "git status", Aliases: []string{"st"}},
+		}},
+		{Title: "Branches", Command: "git branch -a"},
+		{Title: "Docker", Command: "docker ps", Details: "List running containers"},
+	}))
+
+	tests := []struct {
+		query    string
+		settings searchSettings
+		want     []string
+	}{
+		// Exact, then prefix, then word boundary matches
+		{"branch", searchSettings{MinScore: defaultMinScore}, []string{"Branch", "Branches", "Delete branch"}},
+		{"st", searchSettings{MinScore: defaultMinScore}, []string{"Status"}},
+		// Tokens can span the breadcrumb path
+		{"git del", searchSettings{MinScore: defaultMinScore}, []string{"Delete branch"}},
+		{"containers", searchSettings{MinScore: defaultMinScore}, nil},
+		{"containers", searchSettings{MinScore: defaultMinScore, MatchDetails: true}, []string{"Docker"}},
+	}
+	for _, tt := range tests {
+		got := titles(index.Search(tt.query, tt.settings))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q, %+v) = %q, want %q", tt.query, tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestSearchBreaksTiesByTitleThenPath(t *testing.T) {
+	options := []Option{
+		{Title: "Zsh", Children: []Option{{Title: "logs", Command: "z"}}},
+		{Title: "Bash", Children: []Option{{Title: "logs", Command: "b"}}},
+		{Title: "logs", Command: "top"},
+		{Title: "blogs", Command: "blogs"},
+	}
+	// In both orders, so the results don't depend on how the config was merged
+	for _, options := range [][]Option{options, {options[3], options[2], options[1], options[0]}} {
+		var paths []string
+		for _, opt := range newOptionIndex(flattenOptions(options)).Search("logs", searchSettings{MinScore: defaultMinScore}) {
+			paths = append(paths, joinPath(opt.Path, opt.Title))
+		}
+		want := "[logs Bash/logs Zsh/logs blogs]"
+		if fmt.Sprint(paths) != want {
+			t.Errorf("Search() = %q, want %s", paths, want)
+		}
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	options := []Option{
+		{Title: "Deploy", Children: []Option{
+			{Title: "Staging", Command: "make deploy-staging"},
+			{Title: "Production", Command: "make deploy-prod"},
+		}},
+		{Title: "Test", Command: "make test"},
+	}
+	settings := searchSettings{MinScore: defaultMinScore}
+
+	if got, ok := firstMatch(options, "prod", settings); !ok || got.Command != "make deploy-prod" {
+		t.Errorf("firstMatch(prod) = %q, %t, want the production deploy", got.Command, ok)
+	}
+	if got, ok := firstMatch(options, "deploy stag", settings); !ok || got.Command != "make deploy-staging" {
+		t.Errorf("firstMatch(deploy stag) = %q, %t, want the staging deploy", got.Command, ok)
+	}
+	for _, query := range []string{"xyz", "", "  "} {
+		if got, ok := firstMatch(options, query, settings); ok {
+			t.Errorf("firstMatch(%q) = %q, want no match", query, got.Title)
+		}
+	}
+}
+
+func TestSearchMatchesAliases(t *testing.T) {
+	index := newOptionIndex(flattenOptions([]Option{
+		{Title: "Docker", Children: []Option{
+			{Title: "Container list", Command: "docker ps", Aliases: []string{"ps", "Running"}},
+		}},
+		{Title: "Processes", Command: "top"},
+	}))
+	settings := searchSettings{MinScore: defaultMinScore}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Only the alias matches, it scores like a title so it's found
+		{"running", []string{"Container list"}},
+		// An exact alias beats a prefix of another title
+		{"ps", []string{"Container list", "Processes"}},
+		{"container", []string{"Container list"}},
+	}
+	for _, tt := range tests {
+		got := titles(index.Search(tt.query, settings))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAcrossBreadcrumbs(t *testing.T) {
+	index := newOptionIndex(flattenOptions([]Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Branch", Children: []Option{
+				{Title: "Delete", Command: "git branch -d"},
+				{Title: "Rename", Command: "git branch -m"},
+			}},
+		}},
+		{Title: "Docker", Children: []Option{
+			{Title: "Delete", Command: "docker rm"},
+		}},
+	}))
+	settings := searchSettings{MinScore: defaultMinScore}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Each token matches a different segment of Git/Branch/Delete
+		{"git branch del", []string{"Git/Branch/Delete"}},
+		{"git del", []string{"Git/Branch/Delete"}},
+		{"docker del", []string{"Docker/Delete"}},
+		// Tokens in any order, every one has to match
+		{"del docker", []string{"Docker/Delete"}},
+		{"git docker", nil},
+		{"delete", []string{"Docker/Delete", "Git/Branch/Delete"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, opt := range index.Search(tt.query, settings) {
+			got = append(got, joinPath(opt.Path, opt.Title))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkSearch types a query into a config of tens of thousands of options,
+// one search per keystroke like the search box does
+func BenchmarkSearch(b *testing.B) {
+	var categories []Option
+	for i := range 300 {
+		category := Option{Title: fmt.Sprintf("Category %d", i)}
+		for j := range 100 {
+			category.Children = append(category.Children, Option{
+				Title:   fmt.Sprintf("Command %d-%d", i, j),
+				Command: fmt.Sprintf("echo %d %d", i, j),
+				Details: "Prints the numbers of its category and itself",
+				Aliases: []string{fmt.Sprintf("c%d", j)},
+			})
+		}
+		categories = append(categories, category)
+	}
+	index := newOptionIndex(flattenOptions(categories))
+	settings := searchSettings{MinScore: defaultMinScore, MatchDetails: true}
+	query := "cat 12 cmd 4"
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := 1; i <= len(query); i++ {
+			index.Search(query[:i], settings)
+		}
+	}
+}
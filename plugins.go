@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a plugin may take to list its options
+const defaultPluginTimeout = 5 * time.Second
+
+// pluginRunner runs a plugin script and returns what it printed
+type pluginRunner func(ctx context.Context, path string) ([]byte, error)
+
+// runPlugin runs path --list. Plugins may run while the menu is on screen, so
+// stderr is kept out of the terminal and reported with the error when one fails.
+func runPlugin(ctx context.Context, path string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, "--list")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if message := strings.TrimSpace(stderr.String()); err != nil && message != "" {
+		err = fmt.Errorf("%v: %s", err, message)
+	}
+	return output, err
+}
+
+// pluginScripts lists the executable files in dir sorted by name, a missing
+// directory means no plugins
+func pluginScripts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory %s: %v", dir, err)
+	}
+
+	var scripts []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+		scripts = append(scripts, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(scripts)
+	return scripts, nil
+}
+
+// pluginTitle names a plugin's category after its script, without extension
+func pluginTitle(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// loadPlugin runs one plugin and parses its options, the last good output kept
+// in cachePath is used when the plugin fails, times out or prints invalid JSON
+func loadPlugin(run pluginRunner, path string, cachePath string, timeout time.Duration, now time.Time) (options []Option, warning error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	data, err := run(ctx, path)
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", timeout)
+	}
+	if err == nil {
+		options, err = parseOptions(data)
+	}
+	if err == nil {
+		if err := writeConfigCache(cachePath, cachedConfig{URL: path, FetchedAt: now, Config: data}); err != nil {
+			warning = fmt.Errorf("failed to cache plugin %s: %v", path, err)
+		}
+		return options, warning
+	}
+
+	cached, cacheErr := readConfigCache(cachePath)
+	if cacheErr == nil && cached.URL == path {
+		if options, cacheErr := parseOptions(cached.Config); cacheErr == nil {
+			return options, fmt.Errorf("plugin %s: %v, using cached output from %s", path, err, cached.FetchedAt.Format(time.DateTime))
+		}
+	}
+	return nil, fmt.Errorf("plugin %s: %v", path, err)
+}
+
+// orderPlugins sorts plugin scripts into the order they're merged in. Names
+// listed in order come first as listed, the rest follow by file name, or with
+// the "mtime" strategy by modification time, oldest first.
+func orderPlugins(scripts []string, strategy string, order []string, modTime func(string) time.Time) ([]string, error) {
+	if strategy != "" && strategy != "filename" && strategy != "mtime" {
+		return nil, fmt.Errorf("unknown merge strategy %q, use filename or mtime", strategy)
+	}
+
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+	times := make(map[string]time.Time, len(scripts))
+	if strategy == "mtime" {
+		for _, script := range scripts {
+			times[script] = modTime(script)
+		}
+	}
+
+	sorted := append([]string(nil), scripts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		rankA, listedA := rank[pluginTitle(a)]
+		rankB, listedB := rank[pluginTitle(b)]
+		if listedA || listedB {
+			if listedA && listedB {
+				return rankA < rankB
+			}
+			return listedA
+		}
+		if !times[a].Equal(times[b]) {
+			return times[a].Before(times[b])
+		}
+		return filepath.Base(a) < filepath.Base(b)
+	})
+	return sorted, nil
+}
+
+// fileModTime is a file's modification time, the zero time if it can't be read
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// mergeOptions adds extra to options. A category whose title matches a top-level
+// category already there has its children appended to that category, so
+// merging the same sources in the same order always gives the same menu.
+func mergeOptions(options []Option, extra []Option) []Option {
+	merged := append([]Option(nil), options...)
+	for _, opt := range extra {
+		index := -1
+		for i := range merged {
+			if merged[i].Title == opt.Title && len(merged[i].Children) > 0 && len(opt.Children) > 0 {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			merged = append(merged, opt)
+			continue
+		}
+		children := append([]Option(nil), merged[index].Children...)
+		merged[index].Children = append(children, opt.Children...)
+	}
+	return merged
+}
+
+// withSource returns a copy of options with source set on them and everything below them
+func withSource(options []Option, source string) []Option {
+	if options == nil {
+		return nil
+	}
+	result := make([]Option, len(options))
+	for i, opt := range options {
+		opt.Source = source
+		opt.Children = withSource(opt.Children, source)
+		result[i] = opt
+	}
+	return result
+}
+
+// loadPlugins runs the plugin scripts concurrently and returns a top-level
+// category for each one that produced options, in the order of scripts.
+// Plugins that fail are skipped and reported as warnings.
+func loadPlugins(run pluginRunner, scripts []string, cacheDir string, timeout time.Duration, now time.Time) ([]Option, []error) {
+	children := make([][]Option, len(scripts))
+	warnings := make([]error, len(scripts))
+	var wg sync.WaitGroup
+	for i, script := range scripts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cachePath := filepath.Join(cacheDir, pluginTitle(script)+".json")
+			children[i], warnings[i] = loadPlugin(run, script, cachePath, timeout, now)
+		}()
+	}
+	wg.Wait()
+
+	var categories []Option
+	var errs []error
+	for i, script := range scripts {
+		if warnings[i] != nil {
+			errs = append(errs, warnings[i])
+		}
+		if len(children[i]) > 0 {
+			categories = append(categories, Option{
+				Title:    pluginTitle(script),
+				Details:  "Options from plugin " + script,
+				Children: withSource(children[i], script),
+				Source:   script,
+			})
+		}
+	}
+	return categories, errs
+}
+
+// loadInBackground runs load without blocking and hands its result to done
+// through update, which runs done where the UI can be changed, like
+// app.QueueUpdateDraw does
+func loadInBackground(load func() ([]Option, []error), update func(func()), done func([]Option, []error)) {
+	go func() {
+		options, errs := load()
+		update(func() { done(options, errs) })
+	}()
+}
+
+// withLoadingRow ends a menu in a row saying what's still loading, the row is
+// a separator so it can't be selected
+func withLoadingRow(options []Option, loading bool, text string) []Option {
+	if !loading {
+		return options
+	}
+	return append(append([]Option(nil), options...), Option{Title: text, Separator: true})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	outputs := map[string]string{
+		"/plugins/git.sh":    `[{"title": "Status", "command": "git status"}]`,
+		"/plugins/k8s":       `[{"title": "Pods", "command": "kubectl get pods"}]`,
+		"/plugins/empty.sh":  `[]`,
+		"/plugins/broken.sh": `not json`,
+	}
+	run := func(ctx context.Context, path string) ([]byte, error) {
+		switch path {
+		case "/plugins/failing.sh":
+			return nil, errors.New("exit status 1: no repo")
+		case "/plugins/slow.sh":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []byte(outputs[path]), nil
+	}
+	scripts := []string{"/plugins/git.sh", "/plugins/failing.sh", "/plugins/slow.sh", "/plugins/broken.sh", "/plugins/empty.sh", "/plugins/k8s"}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	categories, errs := loadPlugins(run, scripts, t.TempDir(), 50*time.Millisecond, now)
+	if got := titles(categories); !slices.Equal(got, []string{"git", "k8s"}) {
+		t.Errorf("categories = %q, want git and k8s", got)
+	}
+	if len(categories) == 2 {
+		status := categories[0].Children[0]
+		if status.Title != "Status" || status.Source != "/plugins/git.sh" {
+			t.Errorf("git's option = %q from %q, want Status from its script", status.Title, status.Source)
+		}
+	}
+	// Each failing plugin is reported, the others still load
+	if len(errs) != 3 {
+		t.Fatalf("errors = %v, want one each for failing, slow and broken", errs)
+	}
+	for i, want := range []string{"no repo", "timed out", "broken.sh"} {
+		if !strings.Contains(errs[i].Error(), want) {
+			t.Errorf("error %d = %v, want it to mention %q", i, errs[i], want)
+		}
+	}
+}
+
+func TestLoadPluginFallsBackToCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "git.json")
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	output, fail := `[{"title": "Status", "command": "git status"}]`, false
+	run := func(ctx context.Context, path string) ([]byte, error) {
+		if fail {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte(output), nil
+	}
+
+	if options, err := loadPlugin(run, "/plugins/git.sh", cachePath, time.Second, now); err != nil || len(options) != 1 {
+		t.Fatalf("loadPlugin() = %v, %v, want Status", titles(options), err)
+	}
+	fail = true
+	options, err := loadPlugin(run, "/plugins/git.sh", cachePath, time.Second, now.Add(time.Hour))
+	if got := titles(options); !slices.Equal(got, []string{"Status"}) {
+		t.Errorf("loadPlugin() after a failure = %q, want the cached Status", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "using cached output from 2026-10-15 12:00:00") {
+		t.Errorf("warning = %v, want it to say the cache was used", err)
+	}
+	// Another script with the same name doesn't get this one's output
+	if options, err := loadPlugin(run, "/other/git.sh", cachePath, time.Second, now); err == nil || options != nil {
+		t.Errorf("loadPlugin() of another script = %q, %v, want an error", titles(options), err)
+	}
+}
+
+func TestMergeOptions(t *testing.T) {
+	options := []Option{
+		{Title: "Git", Children: []Option{{Title: "Log", Command: "git log"}}},
+		{Title: "Top", Command: "top"},
+	}
+	extra := []Option{
+		{Title: "Git", Children: []Option{{Title: "Status", Command: "git status"}}},
+		{Title: "Top", Children: []Option{{Title: "Htop", Command: "htop"}}},
+		{Title: "k8s", Children: []Option{{Title: "Pods", Command: "kubectl get pods"}}},
+	}
+
+	// Only categories are merged, the Top command and category stay apart
+	merged := mergeOptions(options, extra)
+	if got := titles(merged); !slices.Equal(got, []string{"Git", "Top", "Top", "k8s"}) {
+		t.Errorf("mergeOptions() = %q, want Git, Top, the plugin's Top category and k8s", got)
+	}
+	if got := titles(merged[0].Children); !slices.Equal(got, []string{"Log", "Status"}) {
+		t.Errorf("merged Git = %q, want Log then Status", got)
+	}
+	if len(options[0].Children) != 1 {
+		t.Errorf("mergeOptions() changed the config's own Git category")
+	}
+}
+
+func TestOrderPlugins(t *testing.T) {
+	scripts := []string{"/p/a.sh", "/p/b.sh", "/p/c.sh", "/p/d.sh"}
+	base := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	modTimes := map[string]time.Time{"/p/a.sh": base.Add(3 * time.Hour), "/p/b.sh": base, "/p/c.sh": base.Add(time.Hour), "/p/d.sh": base.Add(2 * time.Hour)}
+	modTime := func(path string) time.Time { return modTimes[path] }
+
+	tests := []struct {
+		strategy string
+		order    []string
+		want     []string
+	}{
+		{"", nil, scripts},
+		{"filename", []string{"d", "c"}, []string{"/p/d.sh", "/p/c.sh", "/p/a.sh", "/p/b.sh"}},
+		{"mtime", nil, []string{"/p/b.sh", "/p/c.sh", "/p/d.sh", "/p/a.sh"}},
+		{"mtime", []string{"a"}, []string{"/p/a.sh", "/p/b.sh", "/p/c.sh", "/p/d.sh"}},
+	}
+	for _, tt := range tests {
+		got, err := orderPlugins(scripts, tt.strategy, tt.order, modTime)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("orderPlugins(%q, %q) = %q, %v, want %q", tt.strategy, tt.order, got, err, tt.want)
+		}
+	}
+
+	// The order scripts are found in doesn't matter
+	shuffled := []string{"/p/c.sh", "/p/a.sh", "/p/d.sh", "/p/b.sh"}
+	if got, err := orderPlugins(shuffled, "filename", nil, modTime); err != nil || !slices.Equal(got, scripts) {
+		t.Errorf("orderPlugins(%q) = %q, %v, want %q", shuffled, got, err, scripts)
+	}
+	if _, err := orderPlugins(scripts, "random", nil, modTime); err == nil {
+		t.Errorf("orderPlugins() with an unknown strategy succeeded")
+	}
+}
+
+func TestPluginScripts(t *testing.T) {
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"git.sh": 0755, "k8s": 0700, "README.md": 0644} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	scripts, err := pluginScripts(dir)
+	if want := []string{filepath.Join(dir, "git.sh"), filepath.Join(dir, "k8s")}; err != nil || !slices.Equal(scripts, want) {
+		t.Errorf("pluginScripts() = %q, %v, want %q", scripts, err, want)
+	}
+	if scripts, err := pluginScripts(filepath.Join(dir, "missing")); err != nil || scripts != nil {
+		t.Errorf("pluginScripts() of a missing directory = %q, %v, want no plugins", scripts, err)
+	}
+}
+
+func TestLoadInBackground(t *testing.T) {
+	config := []Option{{Title: "Build", Command: "make"}}
+	loading := true
+	menu := withLoadingRow(config, loading, "Loading plugins…")
+	if got := titles(menu); !slices.Equal(got, []string{"Build", "Loading plugins…"}) || !menu[1].Separator {
+		t.Fatalf("menu while loading = %q, want Build and a loading row", got)
+	}
+
+	release := make(chan struct{})
+	load := func() ([]Option, []error) {
+		<-release
+		return []Option{{Title: "docker", Children: []Option{{Title: "ps", Command: "docker ps"}}}}, []error{errors.New("plugin k8s: timed out")}
+	}
+	// Updates queue up like they do for the UI, they only run when drained
+	updates := make(chan func(), 1)
+	update := func(f func()) { updates <- f }
+	var errs []error
+	loadInBackground(load, update, func(loaded []Option, pluginErrs []error) {
+		loading = false
+		menu = withLoadingRow(mergeOptions(config, loaded), loading, "Loading plugins…")
+		errs = pluginErrs
+	})
+
+	select {
+	case <-updates:
+		t.Fatal("menu updated before the slow loader finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := titles(menu); !slices.Equal(got, []string{"Build", "Loading plugins…"}) {
+		t.Errorf("menu before the load finished = %q, want it unchanged", got)
+	}
+
+	close(release)
+	select {
+	case f := <-updates:
+		f()
+	case <-time.After(time.Second):
+		t.Fatal("the loaded plugins never reached the menu")
+	}
+	if got := titles(menu); !slices.Equal(got, []string{"Build", "docker"}) {
+		t.Errorf("menu after loading = %q, want Build and docker", got)
+	}
+	if len(errs) != 1 {
+		t.Errorf("plugin errors = %v, want the k8s timeout", errs)
+	}
+}
+
+func TestSourceAfterMerge(t *testing.T) {
+	config := withSource([]Option{
+		{Title: "Git", Children: []Option{{Title: "Log", Command: "git log"}}},
+		{Title: "Top", Command: "top"},
+	}, "/home/me/.talias/options.json")
+	plugin := withSource([]Option{
+		{Title: "Git", Children: []Option{{Title: "Status", Command: "git status"}}},
+		{Title: "k8s", Children: []Option{{Title: "Pods", Command: "kubectl get pods"}}},
+	}, "/home/me/.talias/plugins/work.json")
+
+	merged := mergeOptions(config, plugin)
+	if got := merged[0].Source; got != "/home/me/.talias/options.json" {
+		t.Errorf("merged Git category came from %q, want the config that defined it first", got)
+	}
+	if got := merged[2].Source; got != "/home/me/.talias/plugins/work.json" {
+		t.Errorf("k8s category came from %q, want the plugin", got)
+	}
+
+	tests := []struct {
+		path   string
+		source string
+	}{
+		{"Git/Log", "/home/me/.talias/options.json"},
+		{"Git/Status", "/home/me/.talias/plugins/work.json"},
+		{"Top", "/home/me/.talias/options.json"},
+		{"k8s/Pods", "/home/me/.talias/plugins/work.json"},
+	}
+	for _, tt := range tests {
+		option, ok := findOptionByPath(merged, tt.path)
+		if !ok {
+			t.Errorf("%q not found after the merge", tt.path)
+			continue
+		}
+		if option.Source != tt.source {
+			t.Errorf("%q came from %q, want %q", tt.path, option.Source, tt.source)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+// hasRunnableLeaf reports whether option can run something itself or through
+// any option below it, generated menus count since their children aren't known yet
+func hasRunnableLeaf(option Option) bool {
+	if option.Command != "" || option.EditFile != "" || option.ChildrenCmd != "" {
+		return true
+	}
+	for _, child := range option.Children {
+		if hasRunnableLeaf(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneEmptyCategories drops categories with nothing runnable anywhere below
+// them, including ones that only hold other empty categories or leaves without
+// a command. Such leaves are kept in categories that have something to run.
+func pruneEmptyCategories(options []Option) []Option {
+	var result []Option
+	for _, opt := range options {
+		if opt.Children == nil {
+			result = append(result, opt)
+			continue
+		}
+		if !hasRunnableLeaf(opt) {
+			continue
+		}
+		opt.Children = pruneEmptyCategories(opt.Children)
+		result = append(result, opt)
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPruneEmptyCategories(t *testing.T) {
+	options := []Option{
+		{Title: "macOS", Children: []Option{
+			{Title: "Flush DNS", Command: "dscacheutil -flushcache", When: "MACOS"},
+			{Title: "More", Children: []Option{{Title: "Spotlight", Command: "mdutil -E /", When: "MACOS"}}},
+		}},
+		{Title: "Docs", Children: []Option{{Title: "Read the wiki"}}},
+		{Title: "Git", Children: []Option{
+			{Title: "Status", Command: "git status"},
+			{Title: "Notes", Children: []Option{{Title: "Nothing here"}}},
+			{Title: "Remember to rebase"},
+		}},
+		{Title: "Branches", ChildrenCmd: "git branch"},
+		{Title: "Top", Command: "top"},
+	}
+	lookupEnv := func(string) (string, bool) { return "", false }
+
+	// Filtering empties the macOS branch, including its nested category
+	filtered := filterByCondition(options, lookupEnv)
+	pruned := pruneEmptyCategories(filtered)
+	if got := titles(pruned); !slices.Equal(got, []string{"Git", "Branches", "Top"}) {
+		t.Errorf("pruneEmptyCategories() = %q, want Git, Branches and Top", got)
+	}
+	if got := titles(pruned[0].Children); !slices.Equal(got, []string{"Status", "Remember to rebase"}) {
+		t.Errorf("pruned Git = %q, want Status and the note", got)
+	}
+
+	// Without pruning, documentation-only categories stay
+	if got := titles(filtered); !slices.Equal(got, []string{"macOS", "Docs", "Git", "Branches", "Top"}) {
+		t.Errorf("filterByCondition() = %q, want every top-level option", got)
+	}
+}
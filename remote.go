@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults for fetching a config from a URL
+const (
+	defaultFetchTimeout  = 10 * time.Second
+	defaultMaxConfigSize = 5 << 20 // 5 MiB
+	defaultCacheTTL      = 15 * time.Minute
+)
+
+// isRemoteConfig reports whether the config location is an http(s) URL
+func isRemoteConfig(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// remoteCachePath is where the last fetched copy of url is kept
+func remoteCachePath(cacheDir string, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// fetchConfig downloads a config body, refusing bodies larger than maxSize bytes
+func fetchConfig(client *http.Client, url string, maxSize int64) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", url, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("config at %s is larger than %d bytes", url, maxSize)
+	}
+	return data, nil
+}
+
+// cachedConfig is a fetched config body and when it was fetched
+type cachedConfig struct {
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Config    []byte    `json:"config"` // the body as fetched, JSON or YAML
+}
+
+// readConfigCache loads the cached copy of a fetched config
+func readConfigCache(path string) (cachedConfig, error) {
+	var cached cachedConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cached, fmt.Errorf("failed to read file %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return cached, fmt.Errorf("failed to parse cache %s: %v", path, err)
+	}
+	return cached, nil
+}
+
+// writeConfigCache stores a fetched config body with its fetch time
+func writeConfigCache(path string, cached cachedConfig) error {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// cacheIsFresh reports whether a copy fetched at fetchedAt can be used without refetching
+func cacheIsFresh(fetchedAt time.Time, now time.Time, ttl time.Duration, refresh bool) bool {
+	return !refresh && ttl > 0 && now.Sub(fetchedAt) < ttl
+}
+
+// parseFetchedConfig checks a fetched config against checksum, when one is
+// given, and parses it as JSON or, unless it starts like JSON, as YAML
+func parseFetchedConfig(data []byte, checksum string) (Config, error) {
+	if checksum != "" {
+		if err := verifyChecksum(data, checksum); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := checkText(data); err != nil {
+		return Config{}, err
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return parseConfig(data)
+	}
+
+	converted, err := yamlToJSON(data)
+	if err != nil {
+		return Config{}, err
+	}
+	return parseConfig(converted)
+}
+
+// yamlToJSON re-encodes a YAML document as JSON, so it's decoded by the same
+// field names as a JSON config
+func yamlToJSON(data []byte) ([]byte, error) {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+	}
+	converted, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+	}
+	return converted, nil
+}
+
+// loadConfigFromURL loads the config at url through the cache at cachePath.
+// A copy younger than ttl is used as is unless refresh is set, otherwise the
+// config is fetched and cached again. When fetching or parsing fails any cached
+// copy is used regardless of its age and the failure is returned as a warning,
+// err is only set when there is no usable copy. Both fetched and cached copies
+// must match checksum when one is given, either may be JSON or YAML.
+func loadConfigFromURL(fetch func(url string) ([]byte, error), url string, cachePath string, checksum string, ttl time.Duration, refresh bool, now time.Time) (config Config, warning error, err error) {
+	cached, cacheErr := readConfigCache(cachePath)
+	if cacheErr == nil && cached.URL == url && cacheIsFresh(cached.FetchedAt, now, ttl, refresh) {
+		if config, err := parseFetchedConfig(cached.Config, checksum); err == nil {
+			return config, nil, nil
+		}
+	}
+
+	data, fetchErr := fetch(url)
+	if fetchErr == nil {
+		config, fetchErr = parseFetchedConfig(data, checksum)
+	}
+	if fetchErr == nil {
+		if err := writeConfigCache(cachePath, cachedConfig{URL: url, FetchedAt: now, Config: data}); err != nil {
+			warning = fmt.Errorf("failed to cache config: %v", err)
+		}
+		return config, warning, nil
+	}
+
+	if cacheErr != nil {
+		return Config{}, nil, fmt.Errorf("%v (no cached copy: %v)", fetchErr, cacheErr)
+	}
+	config, err = parseFetchedConfig(cached.Config, checksum)
+	if err != nil {
+		return Config{}, nil, fmt.Errorf("%v (cached copy is invalid: %v)", fetchErr, err)
+	}
+	return config, fmt.Errorf("%v, using cached copy from %s", fetchErr, cached.FetchedAt.Format(time.DateTime)), nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFromURL(t *testing.T) {
+	const url = "https://example.com/options.json"
+	first := []byte(`[{"title": "First", "command": "echo 1"}]`)
+	second := []byte(`[{"title": "Second", "command": "echo 2"}]`)
+	cachePath := filepath.Join(t.TempDir(), "cache", "options.json")
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	fetches := 0
+	serve := func(data []byte, err error) func(string) ([]byte, error) {
+		return func(string) ([]byte, error) {
+			fetches++
+			return data, err
+		}
+	}
+	load := func(fetch func(string) ([]byte, error), checksum string, refresh bool, now time.Time) (string, error, error) {
+		config, warning, err := loadConfigFromURL(fetch, url, cachePath, checksum, time.Minute, refresh, now)
+		if err != nil || len(config.Options) == 0 {
+			return "", warning, err
+		}
+		return config.Options[0].Title, warning, err
+	}
+	offline := serve(nil, errors.New("offline"))
+
+	// Nothing cached and no connection
+	if _, _, err := load(offline, "", false, start); err == nil {
+		t.Fatal("loaded a config with nothing to load it from")
+	}
+
+	if title, warning, err := load(serve(first, nil), "", false, start); title != "First" || warning != nil || err != nil {
+		t.Fatalf("fetch = %q, %v, %v, want First", title, warning, err)
+	}
+
+	// A fresh copy is used without fetching
+	fetches = 0
+	if title, _, _ := load(serve(second, nil), "", false, start.Add(30*time.Second)); title != "First" || fetches != 0 {
+		t.Errorf("fresh cache = %q after %d fetches, want First without fetching", title, fetches)
+	}
+	// Unless a refresh is asked for
+	if title, _, _ := load(serve(second, nil), "", true, start.Add(30*time.Second)); title != "Second" || fetches != 1 {
+		t.Errorf("refresh = %q after %d fetches, want Second after one", title, fetches)
+	}
+
+	// A stale copy is still used when fetching fails, with a warning
+	title, warning, err := load(offline, "", false, start.Add(time.Hour))
+	if title != "Second" || err != nil || warning == nil || !strings.Contains(warning.Error(), "using cached copy") {
+		t.Errorf("stale cache = %q, %v, %v, want Second with a warning", title, warning, err)
+	}
+
+	// Neither a fetched nor a cached copy is used when it doesn't match the checksum
+	sum := sha256.Sum256(first)
+	checksum := hex.EncodeToString(sum[:])
+	if _, _, err := load(serve(second, nil), checksum, true, start.Add(time.Hour)); err == nil {
+		t.Error("loaded a config that doesn't match the checksum")
+	}
+	if title, _, err := load(serve(first, nil), checksum, true, start.Add(time.Hour)); title != "First" || err != nil {
+		t.Errorf("matching checksum = %q, %v, want First", title, err)
+	}
+}
+
+const sampleYAMLMenu = `# Shared by the platform team
+options:
+  - title: Deploy
+    children:
+      - title: Staging
+        command: make deploy ENV=staging
+        timeoutSeconds: 600
+      - title: Production
+        command: make deploy ENV=prod
+        confirm: true
+`
+
+func TestFetchConfigFromServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/menu.json":
+			fmt.Fprint(w, `{"options": [{"title": "Status", "command": "git status"}]}`)
+		case "/menu.yaml":
+			fmt.Fprint(w, sampleYAMLMenu)
+		case "/big.json":
+			fmt.Fprint(w, "["+strings.Repeat(" ", 2000)+"]")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	fetch := func(url string) ([]byte, error) {
+		return fetchConfig(server.Client(), url, 1000)
+	}
+
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	load := func(path string, checksum string, now time.Time) (Config, error, error) {
+		url := server.URL + path
+		cachePath := remoteCachePath(t.TempDir(), url)
+		return loadConfigFromURL(fetch, url, cachePath, checksum, time.Minute, false, now)
+	}
+
+	config, warning, err := load("/menu.json", "", start)
+	if err != nil || warning != nil || len(config.Options) != 1 || config.Options[0].Command != "git status" {
+		t.Errorf("JSON menu = %+v, %v, %v", config.Options, warning, err)
+	}
+
+	config, _, err = load("/menu.yaml", "", start)
+	if err != nil {
+		t.Fatalf("YAML menu failed: %v", err)
+	}
+	deploy := config.Options[0]
+	if deploy.Title != "Deploy" || len(deploy.Children) != 2 {
+		t.Fatalf("YAML menu = %+v, want Deploy with two children", config.Options)
+	}
+	if staging := deploy.Children[0]; staging.Command != "make deploy ENV=staging" || staging.TimeoutSeconds != 600 {
+		t.Errorf("staging = %+v, want its command and timeout", staging)
+	}
+	if !deploy.Children[1].Confirm {
+		t.Error("production doesn't ask for confirmation")
+	}
+
+	for _, path := range []string{"/big.json", "/missing.json"} {
+		if _, _, err := load(path, "", start); err == nil {
+			t.Errorf("loading %s succeeded, want an error", path)
+		}
+	}
+
+	// The YAML body is verified as served, not as converted
+	sum := sha256.Sum256([]byte(sampleYAMLMenu))
+	if _, _, err := load("/menu.yaml", hex.EncodeToString(sum[:]), start); err != nil {
+		t.Errorf("matching checksum failed: %v", err)
+	}
+	if _, _, err := load("/menu.yaml", strings.Repeat("0", 64), start); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("mismatching checksum = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestLoadConfigFromServerCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, sampleYAMLMenu)
+	}))
+	fetch := func(url string) ([]byte, error) {
+		return fetchConfig(server.Client(), url, defaultMaxConfigSize)
+	}
+	url := server.URL + "/menu.yaml"
+	cachePath := remoteCachePath(t.TempDir(), url)
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	load := func(now time.Time) (Config, error, error) {
+		return loadConfigFromURL(fetch, url, cachePath, "", time.Minute, false, now)
+	}
+
+	for _, now := range []time.Time{start, start.Add(59 * time.Second)} {
+		if _, _, err := load(now); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests within the TTL, want 1", got)
+	}
+	if _, _, err := load(start.Add(2 * time.Minute)); err != nil || requests.Load() != 2 {
+		t.Errorf("stale cache = %v after %d requests, want it fetched again", err, requests.Load())
+	}
+
+	// The cached YAML copy is used once the server is gone
+	server.Close()
+	config, warning, err := load(start.Add(time.Hour))
+	if err != nil || warning == nil || len(config.Options) != 1 {
+		t.Errorf("offline = %+v, %v, %v, want the cached menu with a warning", config.Options, warning, err)
+	}
+}
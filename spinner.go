@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// Frames and frame rate of the loading spinner
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// spinner animates a loading indicator from a background goroutine while an
+// async load is in flight
+type spinner struct {
+	ticks   <-chan time.Time
+	update  func(func())       // runs a function on the UI goroutine, e.g. app.QueueUpdateDraw
+	render  func(frame string) // shows a frame, only ever called through update
+	cleanup func()             // called once the goroutine has exited
+
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	stopped atomic.Bool
+}
+
+func newSpinner(ticks <-chan time.Time, update func(func()), render func(frame string)) *spinner {
+	return &spinner{
+		ticks:  ticks,
+		update: update,
+		render: render,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start animates until Stop is called or ctx is done, pass a context that is
+// cancelled when the app stops so no goroutine outlives the UI
+func (s *spinner) Start(ctx context.Context) {
+	go func() {
+		defer close(s.done)
+		if s.cleanup != nil {
+			defer s.cleanup()
+		}
+
+		frame := 0
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-s.ticks:
+				text := spinnerFrames[frame%len(spinnerFrames)]
+				frame++
+				s.update(func() {
+					// The load may have finished while this update was queued
+					if !s.stopped.Load() {
+						s.render(text)
+					}
+				})
+			}
+		}
+	}()
+}
+
+// Stop ends the animation, it doesn't wait for the goroutine so it is safe to
+// call from the UI goroutine, further calls are no-ops
+func (s *spinner) Stop() {
+	s.once.Do(func() {
+		s.stopped.Store(true)
+		close(s.stop)
+	})
+}
+
+// Done is closed once the animation goroutine has exited
+func (s *spinner) Done() <-chan struct{} {
+	return s.done
+}
+
+// startSpinner shows message with an animated spinner in view until the returned spinner is stopped
+func startSpinner(ctx context.Context, app *tview.Application, view *tview.TextView, message string) *spinner {
+	ticker := time.NewTicker(spinnerInterval)
+	s := newSpinner(ticker.C,
+		func(f func()) { app.QueueUpdateDraw(f) },
+		func(frame string) { view.SetText(frame + " " + message) })
+	s.cleanup = ticker.Stop
+	view.SetText(spinnerFrames[0] + " " + message)
+	s.Start(ctx)
+	return s
+}
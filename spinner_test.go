@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// waitDone fails the test when the spinner's goroutine doesn't exit
+func waitDone(t *testing.T, s *spinner) {
+	t.Helper()
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("spinner goroutine is still running")
+	}
+}
+
+func TestSpinnerStartStop(t *testing.T) {
+	ticks := make(chan time.Time)
+	var frames []string
+	cleanedUp := false
+	// Updates run right away instead of being queued on the UI goroutine
+	s := newSpinner(ticks, func(f func()) { f() }, func(frame string) {
+		frames = append(frames, frame)
+	})
+	s.cleanup = func() { cleanedUp = true }
+
+	s.Start(context.Background())
+	for range len(spinnerFrames) + 1 {
+		ticks <- time.Now()
+	}
+	s.Stop()
+	s.Stop() // further calls are no-ops
+	waitDone(t, s)
+
+	want := append(slices.Clone(spinnerFrames), spinnerFrames[0])
+	if !slices.Equal(frames, want) {
+		t.Errorf("frames = %q, want every frame and then the first again", frames)
+	}
+	if !cleanedUp {
+		t.Error("cleanup wasn't called")
+	}
+}
+
+func TestSpinnerStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newSpinner(make(chan time.Time), func(f func()) { f() }, func(string) {})
+	s.Start(ctx)
+	// The app stopping ends the animation without Stop
+	cancel()
+	waitDone(t, s)
+}
+
+func TestSpinnerDropsQueuedFramesAfterStop(t *testing.T) {
+	ticks := make(chan time.Time)
+	var queued []func()
+	rendered := 0
+	s := newSpinner(ticks, func(f func()) { queued = append(queued, f) }, func(string) {
+		rendered++
+	})
+
+	s.Start(context.Background())
+	ticks <- time.Now()
+	ticks <- time.Now() // the first update is queued once this is received
+	s.Stop()
+	waitDone(t, s)
+
+	// The load finished before the UI got to the queued updates
+	for _, f := range queued {
+		f()
+	}
+	if rendered != 0 {
+		t.Errorf("rendered %d frames after Stop, want none", rendered)
+	}
+}
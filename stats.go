@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Title of the generated top-level category listing the most used commands
+const mostUsedTitle = "Most used"
+
+// CommandStats tracks how often and when a command was last executed
+type CommandStats struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// loadStats reads execution stats keyed by command, a missing file means no stats yet
+func loadStats(filename string) (map[string]CommandStats, error) {
+	stats := make(map[string]CommandStats)
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse stats: %v", err)
+	}
+	return stats, nil
+}
+
+// saveStats writes execution stats, creating the parent directory if needed
+func saveStats(filename string, stats map[string]CommandStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", filename, err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %v", filename, err)
+	}
+	return nil
+}
+
+// shouldRecord reports whether an execution may be stored, options marked
+// NoHistory and commands matching redact (raw or expanded) are never stored
+func shouldRecord(option Option, command string, redact *regexp.Regexp) bool {
+	if option.NoHistory {
+		return false
+	}
+	if redact != nil && (redact.MatchString(option.Command) || redact.MatchString(command)) {
+		return false
+	}
+	return true
+}
+
+// recordExecution bumps the count for an option's command
+func recordExecution(stats map[string]CommandStats, option Option, now time.Time) {
+	entry := stats[option.Command]
+	entry.Count++
+	entry.LastUsed = now
+	stats[option.Command] = entry
+}
+
+// topCommandsByCount returns up to n commands ordered by execution count, ties
+// go to the most recently used and then alphabetically for a stable order
+func topCommandsByCount(stats map[string]CommandStats, n int) []string {
+	commands := make([]string, 0, len(stats))
+	for command, entry := range stats {
+		if entry.Count > 0 {
+			commands = append(commands, command)
+		}
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		a, b := stats[commands[i]], stats[commands[j]]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if !a.LastUsed.Equal(b.LastUsed) {
+			return a.LastUsed.After(b.LastUsed)
+		}
+		return commands[i] < commands[j]
+	})
+
+	if len(commands) > n {
+		commands = commands[:n]
+	}
+	return commands
+}
+
+// lastUsedOption finds the option whose command was executed most recently
+func lastUsedOption(stats map[string]CommandStats, options []Option) (Option, bool) {
+	var last Option
+	var lastUsed time.Time
+	found := false
+	for _, opt := range flattenOptions(options) {
+		entry, exists := stats[opt.Command]
+		if exists && opt.Command != "" && entry.LastUsed.After(lastUsed) {
+			last, lastUsed, found = opt, entry.LastUsed, true
+		}
+	}
+	return last, found
+}
+
+// mostUsedOption builds the "Most used" category from the top n commands that
+// still exist in options, returns false when there is nothing to show
+func mostUsedOption(stats map[string]CommandStats, options []Option, n int) (Option, bool) {
+	byCommand := make(map[string]Option)
+	for _, opt := range flattenOptions(options) {
+		if _, exists := byCommand[opt.Command]; !exists && opt.Command != "" {
+			byCommand[opt.Command] = opt
+		}
+	}
+
+	var children []Option
+	for _, command := range topCommandsByCount(stats, len(stats)) {
+		if len(children) == n {
+			break
+		}
+		if opt, exists := byCommand[command]; exists {
+			children = append(children, opt)
+		}
+	}
+	if len(children) == 0 {
+		return Option{}, false
+	}
+
+	return Option{
+		Title:    mostUsedTitle,
+		Details:  "Your most frequently run commands",
+		Children: children,
+	}, true
+}
+
+// withMostUsed prepends the "Most used" category to the configured options when there are stats for it
+func withMostUsed(options []Option, stats map[string]CommandStats, n int) []Option {
+	if n <= 0 {
+		return options
+	}
+	if mostUsed, ok := mostUsedOption(stats, options, n); ok {
+		return append([]Option{mostUsed}, options...)
+	}
+	return options
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTopCommandsByCount(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := map[string]CommandStats{
+		"git status": {Count: 12, LastUsed: now.Add(-time.Hour)},
+		"make test":  {Count: 30, LastUsed: now.Add(-48 * time.Hour)},
+		"ls -la":     {Count: 5, LastUsed: now.Add(-time.Minute)},
+		"docker ps":  {Count: 5, LastUsed: now.Add(-2 * time.Minute)},
+		"htop":       {Count: 5, LastUsed: now.Add(-2 * time.Minute)},
+		"unused":     {Count: 0, LastUsed: now},
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{2, []string{"make test", "git status"}},
+		// Equal counts go to the most recent first, then alphabetically
+		{5, []string{"make test", "git status", "ls -la", "docker ps", "htop"}},
+		{10, []string{"make test", "git status", "ls -la", "docker ps", "htop"}},
+		{0, []string{}},
+	}
+	for _, tt := range tests {
+		if got := topCommandsByCount(stats, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("topCommandsByCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWithMostUsed(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	options := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Status", Command: "git status"},
+			{Title: "Log", Command: "git log"},
+		}},
+		{Title: "Test", Command: "make test"},
+	}
+	stats := map[string]CommandStats{
+		"git log":   {Count: 3, LastUsed: now},
+		"make test": {Count: 7, LastUsed: now},
+		"removed":   {Count: 50, LastUsed: now}, // no longer in the config
+	}
+
+	got := withMostUsed(options, stats, 5)
+	if len(got) != 3 || got[0].Title != mostUsedTitle {
+		t.Fatalf("withMostUsed() = %q, want %q first", titles(got), mostUsedTitle)
+	}
+	if children := titles(got[0].Children); !slices.Equal(children, []string{"Test", "Log"}) {
+		t.Errorf("most used = %q, want [Test Log]", children)
+	}
+	if got := withMostUsed(options, stats, 0); len(got) != len(options) {
+		t.Errorf("withMostUsed(n=0) = %q, want the options unchanged", titles(got))
+	}
+}
+
+func TestNoHistoryOptionsAreNotRecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	redact := regexp.MustCompile(`--password`)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	stats := make(map[string]CommandStats)
+	runs := []struct {
+		option  Option
+		command string
+	}{
+		{Option{Title: "Status", Command: "git status"}, "git status"},
+		{Option{Title: "Token", Command: "export TOKEN=abc123", NoHistory: true}, "export TOKEN=abc123"},
+		{Option{Title: "Login", Command: "login --password secret"}, "login --password secret"},
+		// Redacted as it runs too, not only as configured
+		{Option{Title: "Env", Command: "login $FLAGS"}, "login --password secret"},
+	}
+	for _, run := range runs {
+		if shouldRecord(run.option, run.command, redact) {
+			recordExecution(stats, run.option, now)
+		}
+	}
+	if err := saveStats(path, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved["git status"].Count != 1 {
+		t.Errorf("saved stats = %v, want only git status", saved)
+	}
+	data, _ := os.ReadFile(path)
+	for _, secret := range []string{"abc123", "secret", "FLAGS"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("stats file contains %q", secret)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// tagFlags collects the values of a repeated --tag flag
+type tagFlags []string
+
+func (t *tagFlags) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *tagFlags) Set(value string) error {
+	*t = append(*t, value)
+	return nil
+}
+
+// parseTagMode tells whether options need every tag given with --tag ("all")
+// or any of them ("any")
+func parseTagMode(mode string) (matchAll bool, err error) {
+	switch mode {
+	case "any":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	return false, fmt.Errorf("tag mode has to be \"any\" or \"all\", got %q", mode)
+}
+
+// hasTags reports whether optionTags contain all of tags, or any of them
+func hasTags(optionTags []string, tags []string, matchAll bool) bool {
+	for _, tag := range tags {
+		found := slices.Contains(optionTags, tag)
+		if found && !matchAll {
+			return true
+		}
+		if !found && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+// filterByTags keeps the options carrying tags and the categories leading to
+// them. A category's tags count for everything below it, so a matching
+// category is kept whole.
+func filterByTags(options []Option, tags []string, matchAll bool) []Option {
+	return filterByTagsBelow(options, nil, tags, matchAll)
+}
+
+func filterByTagsBelow(options []Option, inherited []string, tags []string, matchAll bool) []Option {
+	var result []Option
+	for _, opt := range options {
+		optionTags := append(slices.Clip(inherited), opt.Tags...)
+		if hasTags(optionTags, tags, matchAll) {
+			result = append(result, opt)
+			continue
+		}
+		if len(opt.Children) == 0 {
+			continue
+		}
+		if children := filterByTagsBelow(opt.Children, optionTags, tags, matchAll); len(children) > 0 {
+			opt.Children = children
+			result = append(result, opt)
+		}
+	}
+	return result
+}
+
+// Title of the generated top-level category listing options by tag
+const tagsTitle = "Tags"
+
+// collectTags lists every tag used in the tree once, sorted
+func collectTags(options []Option) []string {
+	var tags []string
+	for _, opt := range options {
+		tags = append(tags, opt.Tags...)
+		tags = append(tags, collectTags(opt.Children)...)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// taggedOptions lists the options carrying tag with their path set, a tagged
+// category is listed with its children
+func taggedOptions(options []Option, path string, tag string) []Option {
+	var result []Option
+	for _, opt := range options {
+		if slices.Contains(opt.Tags, tag) {
+			tagged := opt
+			tagged.Path = path
+			if tagged.Subtitle == "" {
+				// Where the option lives, the Tags menu mixes all of them
+				tagged.Subtitle = path
+			}
+			result = append(result, tagged)
+		}
+		result = append(result, taggedOptions(opt.Children, joinPath(path, opt.Title), tag)...)
+	}
+	return result
+}
+
+// tagsOption builds the "Tags" category with a category per tag listing the
+// options carrying it, returns false when no option has tags
+func tagsOption(options []Option) (Option, bool) {
+	var children []Option
+	for _, tag := range collectTags(options) {
+		children = append(children, Option{
+			Title:    tag,
+			Details:  fmt.Sprintf("Options tagged %s", tag),
+			Children: taggedOptions(options, "", tag),
+		})
+	}
+	if len(children) == 0 {
+		return Option{}, false
+	}
+
+	return Option{
+		Title:    tagsTitle,
+		Details:  "Options by tag",
+		Children: children,
+	}, true
+}
+
+// withTags appends the "Tags" category to options when any of them has tags
+func withTags(options []Option) []Option {
+	if tags, ok := tagsOption(options); ok {
+		return append(slices.Clip(options), tags)
+	}
+	return options
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterByTags(t *testing.T) {
+	options := []Option{
+		{Title: "Docker", Tags: []string{"containers"}, Children: []Option{
+			{Title: "Prune", Command: "docker system prune", Tags: []string{"cleanup"}},
+			{Title: "List", Command: "docker ps"},
+		}},
+		{Title: "Git", Children: []Option{
+			{Title: "Clean", Command: "git clean -fd", Tags: []string{"cleanup", "git"}},
+			{Title: "Log", Command: "git log"},
+		}},
+		{Title: "Top", Command: "top"},
+	}
+
+	tests := []struct {
+		tags     []string
+		matchAll bool
+		want     string
+	}{
+		// A matching category is kept whole
+		{[]string{"containers"}, false, "[Docker/Prune Docker/List]"},
+		{[]string{"cleanup"}, false, "[Docker/Prune Git/Clean]"},
+		{[]string{"git", "containers"}, false, "[Docker/Prune Docker/List Git/Clean]"},
+		// The category's tags count for the options below it
+		{[]string{"containers", "cleanup"}, true, "[Docker/Prune]"},
+		{[]string{"nope"}, false, "[]"},
+	}
+	for _, tt := range tests {
+		var paths []string
+		for _, opt := range flattenOptions(filterByTags(options, tt.tags, tt.matchAll)) {
+			paths = append(paths, joinPath(opt.Path, opt.Title))
+		}
+		if got := fmt.Sprint(paths); got != tt.want {
+			t.Errorf("filterByTags(%q, all=%t) = %s, want %s", tt.tags, tt.matchAll, got, tt.want)
+		}
+	}
+}
+
+func TestTagsOption(t *testing.T) {
+	options := []Option{
+		{Title: "Git", Children: []Option{
+			{Title: "Clean", Command: "git clean -fd", Tags: []string{"cleanup", "git"}},
+		}},
+		{Title: "Prune", Command: "docker system prune", Tags: []string{"cleanup"}},
+	}
+
+	tags, ok := tagsOption(options)
+	if !ok {
+		t.Fatal("tagsOption() found no tags")
+	}
+	if got := fmt.Sprint(titles(tags.Children)); got != "[cleanup git]" {
+		t.Errorf("tag categories = %s, want [cleanup git]", got)
+	}
+	cleanup := tags.Children[0].Children
+	if got := fmt.Sprint(titles(cleanup)); got != "[Clean Prune]" {
+		t.Errorf("options tagged cleanup = %s, want [Clean Prune]", got)
+	}
+	if cleanup[0].Subtitle != "Git" {
+		t.Errorf("subtitle of Clean = %q, want where it lives, Git", cleanup[0].Subtitle)
+	}
+
+	if _, ok := tagsOption([]Option{{Title: "Top", Command: "top"}}); ok {
+		t.Error("tagsOption() built a category without any tags")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// loadTemplates reads named partial options from filename, a missing file means no templates
+func loadTemplates(filename string) (map[string]Option, error) {
+	templates := make(map[string]Option)
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %v", err)
+	}
+	return templates, nil
+}
+
+// combineTemplates adds the templates of templates.json to the config's own,
+// the config's template wins when both have one of the same name
+func combineTemplates(own map[string]Option, extra map[string]Option) map[string]Option {
+	combined := make(map[string]Option, len(own)+len(extra))
+	for name, template := range extra {
+		combined[name] = template
+	}
+	for name, template := range own {
+		combined[name] = template
+	}
+	return combined
+}
+
+// Option fields that always stay the option's own, never taken from a template.
+// Keys would collide between every option using the template, and a
+// template's childrenCmd, separator or defaultChild would change what kind of
+// entry the option is.
+var ownFields = map[string]bool{
+	"Title":        true,
+	"Children":     true,
+	"Use":          true,
+	"Hotkey":       true,
+	"Chord":        true,
+	"ChildrenCmd":  true,
+	"Separator":    true,
+	"DefaultChild": true,
+}
+
+// mergeTemplate fills every field the option leaves empty from the template,
+// except for ownFields. It goes over all fields so ones added to Option later
+// are merged too.
+func mergeTemplate(option Option, template Option) Option {
+	merged := reflect.ValueOf(&option).Elem()
+	from := reflect.ValueOf(template)
+	for i := 0; i < merged.NumField(); i++ {
+		field := merged.Type().Field(i)
+		if ownFields[field.Name] || field.Tag.Get("json") == "-" {
+			continue
+		}
+		value := merged.Field(i)
+		if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			value.Set(from.Field(i))
+		}
+	}
+	return option
+}
+
+// applyTemplates merges the template named by each option's Use field into it, recursively
+func applyTemplates(options []Option, templates map[string]Option) ([]Option, error) {
+	result := make([]Option, len(options))
+	for i, opt := range options {
+		if opt.Use != "" {
+			template, exists := templates[opt.Use]
+			if !exists {
+				return nil, fmt.Errorf("option %q uses unknown template %q", opt.Title, opt.Use)
+			}
+			opt = mergeTemplate(opt, template)
+		}
+
+		if len(opt.Children) > 0 {
+			children, err := applyTemplates(opt.Children, templates)
+			if err != nil {
+				return nil, err
+			}
+			opt.Children = children
+		}
+		result[i] = opt
+	}
+	return result, nil
+}
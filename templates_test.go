@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeTemplate(t *testing.T) {
+	template := Option{
+		Title:          "template title",
+		Details:        "Deploys the service",
+		Command:        "make deploy",
+		Confirm:        true,
+		ConfirmPhrase:  "prod",
+		Shell:          "bash",
+		Level:          levelDanger,
+		Tags:           []string{"infra"},
+		TimeoutSeconds: 600,
+		Children:       []Option{{Title: "template child"}},
+	}
+	option := Option{
+		Title:   "Deploy API",
+		Details: "Deploys the API",
+		Use:     "deploy",
+	}
+
+	got := mergeTemplate(option, template)
+	want := Option{
+		Title:          "Deploy API",
+		Details:        "Deploys the API",
+		Use:            "deploy",
+		Command:        "make deploy",
+		Confirm:        true,
+		ConfirmPhrase:  "prod",
+		Shell:          "bash",
+		Level:          levelDanger,
+		Tags:           []string{"infra"},
+		TimeoutSeconds: 600,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeTemplate() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeTemplateKeepsOwnValues(t *testing.T) {
+	template := Option{Command: "make deploy", Level: levelDanger, Aliases: []string{"ship"}}
+	option := Option{Title: "Deploy", Command: "make deploy-api", Level: levelWarn, Aliases: []string{}}
+
+	got := mergeTemplate(option, template)
+	if got.Command != "make deploy-api" || got.Level != levelWarn {
+		t.Errorf("mergeTemplate() overwrote the option's own values: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Aliases, []string{"ship"}) {
+		t.Errorf("Aliases = %q, want the template's for an empty list", got.Aliases)
+	}
+}
+
+func TestTemplatesFromConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`{
+		"templates": {
+			"deploy": {"command": "make deploy", "notify": true, "timeoutSeconds": 600}
+		},
+		"options": [
+			{"title": "Deploy", "use": "deploy"},
+			{"title": "Services", "children": [
+				{"title": "Deploy API", "use": "deploy", "command": "make deploy-api"}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// templates.json can add templates, the config's own win
+	file := map[string]Option{
+		"deploy": {Command: "make old-deploy", Shell: "bash"},
+		"lint":   {Command: "make lint"},
+	}
+	templates := combineTemplates(config.Templates, file)
+	if templates["deploy"].Command != "make deploy" || templates["deploy"].Shell != "" || templates["lint"].Command != "make lint" {
+		t.Errorf("combineTemplates() = %+v, want the config's deploy and the file's lint", templates)
+	}
+
+	options, err := applyTemplates(config.Options, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deploy := options[0]; deploy.Command != "make deploy" || !deploy.Notify || deploy.TimeoutSeconds != 600 {
+		t.Errorf("Deploy = %+v, want the template's fields", deploy)
+	}
+	// Nested options are templated too, their own fields take precedence
+	if api := options[1].Children[0]; api.Command != "make deploy-api" || !api.Notify || api.TimeoutSeconds != 600 {
+		t.Errorf("Deploy API = %+v, want its own command and the template's other fields", api)
+	}
+}
+
+func TestApplyTemplatesUnknownName(t *testing.T) {
+	options := []Option{{Title: "Services", Children: []Option{{Title: "Deploy", Use: "deploy"}}}}
+	_, err := applyTemplates(options, map[string]Option{"lint": {Command: "make lint"}})
+	if err == nil || err.Error() != `option "Deploy" uses unknown template "deploy"` {
+		t.Errorf("applyTemplates() error = %v, want the unknown template named", err)
+	}
+}
+
+func TestMergeTemplateSkipsOwnFields(t *testing.T) {
+	template := Option{
+		Command:      "make deploy",
+		Hotkey:       "d",
+		Chord:        "g d",
+		ChildrenCmd:  "ls deploy/",
+		Separator:    true,
+		DefaultChild: "API",
+		Children:     []Option{{Title: "API", Command: "make deploy-api"}},
+	}
+	got := mergeTemplate(Option{Title: "Deploy", Use: "deploy"}, template)
+	want := Option{Title: "Deploy", Use: "deploy", Command: "make deploy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeTemplate() = %+v, want only the command inherited", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// theme holds the colors of the UI
+type theme struct {
+	Highlight tcell.Color // matched characters in search results
+	Selected  tcell.Color // background of the selected list item
+	Border    tcell.Color // grid borders
+
+	// Background of the panes, tcell.ColorDefault leaves the terminal's own
+	// background showing through
+	Background tcell.Color
+
+	Selection string // how the selected list item stands out, see selectionText
+
+	Levels map[string]tcell.Color // titles of options by their level
+}
+
+var defaultTheme = theme{
+	Highlight:  tcell.ColorYellow,
+	Selected:   tcell.ColorWhite,
+	Border:     tcell.ColorWhite,
+	Background: tcell.ColorDefault,
+	Selection:  selectionHighlight,
+	Levels: map[string]tcell.Color{
+		levelInfo:   tcell.ColorGreen,
+		levelWarn:   tcell.ColorYellow,
+		levelDanger: tcell.ColorRed,
+	},
+}
+
+// Levels an option can have, telling safe and dangerous options apart at a glance
+const (
+	levelInfo   = "info"
+	levelWarn   = "warn"
+	levelDanger = "danger"
+)
+
+// isLevel reports whether level is one of the known levels
+func isLevel(level string) bool {
+	return level == levelInfo || level == levelWarn || level == levelDanger
+}
+
+// levelColor is the color of an option's level, false for options without one
+func (t theme) levelColor(level string) (tcell.Color, bool) {
+	color, ok := t.Levels[level]
+	return color, ok
+}
+
+// levelText colors text, an option's row in the menu, by the option's level.
+// Leaves without a command keep the gray they're shown in.
+func (t theme) levelText(option Option, text string) string {
+	if color, ok := t.levelColor(option.Level); ok && !isMissingCommand(option) {
+		return colorTag(color) + text + "[-]"
+	}
+	return text
+}
+
+// colorTag is the tview tag switching text to color
+func colorTag(color tcell.Color) string {
+	return fmt.Sprintf("[#%06x]", color.Hex())
+}
+
+// Ways the selected list item stands out: in reverse colors, with a marker in
+// front of it or both
+const (
+	selectionHighlight = "highlight"
+	selectionArrow     = "arrow"
+	selectionBoth      = "both"
+)
+
+// Put in front of the selected item when the selection style has a marker
+const selectionMarker = "▶ "
+
+// selectionText is the text of a list item for the selection style, styles
+// with a marker indent the items that aren't selected to keep them aligned
+func selectionText(text string, selected bool, style string) string {
+	if style != selectionArrow && style != selectionBoth {
+		return text
+	}
+	if selected {
+		return selectionMarker + text
+	}
+	return strings.Repeat(" ", utf8.RuneCountInString(selectionMarker)) + text
+}
+
+// withSolidBackground paints the panes in tview's background color instead of
+// letting the terminal's show through, for terminals that render that badly
+func (t theme) withSolidBackground() theme {
+	t.Background = tview.Styles.PrimitiveBackgroundColor
+	return t
+}
+
+// parseColor accepts tcell color names like "teal" and hex colors like "#ff8800"
+func parseColor(name string) (tcell.Color, error) {
+	color := tcell.GetColor(strings.ToLower(strings.TrimSpace(name)))
+	if color == tcell.ColorDefault {
+		return color, fmt.Errorf("unknown color %q", name)
+	}
+	return color, nil
+}
+
+// themeFromEnv overrides colors of base with TALIAS_HIGHLIGHT, TALIAS_SELECTED
+// and TALIAS_BORDER, unknown colors are reported and leave the base color
+func themeFromEnv(base theme, getenv func(string) string) (theme, []error) {
+	var errs []error
+	for name, color := range map[string]*tcell.Color{
+		"TALIAS_HIGHLIGHT": &base.Highlight,
+		"TALIAS_SELECTED":  &base.Selected,
+		"TALIAS_BORDER":    &base.Border,
+	} {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseColor(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		*color = parsed
+	}
+	return base, errs
+}
+
+// highlightMatch marks the characters of title that query matched in color,
+// a substring match is preferred over scattered characters. The rest of the
+// title is in base, tcell.ColorDefault leaves it uncolored.
+func highlightMatch(title string, query string, color tcell.Color, base tcell.Color) string {
+	baseTag, reset := "", "[-]"
+	if base != tcell.ColorDefault {
+		baseTag = colorTag(base)
+		reset = baseTag
+	}
+	runes := []rune(title)
+	lower := []rune(strings.ToLower(title))
+	needle := []rune(strings.ToLower(query))
+	if len(needle) == 0 || len(lower) != len(runes) {
+		return withBase(baseTag, tview.Escape(title))
+	}
+
+	matched := make([]bool, len(runes))
+	if start := strings.Index(string(lower), string(needle)); start >= 0 {
+		start = len([]rune(string(lower)[:start]))
+		for i := range needle {
+			matched[start+i] = true
+		}
+	} else {
+		next := 0
+		for i, r := range lower {
+			if next < len(needle) && r == needle[next] {
+				matched[i] = true
+				next++
+			}
+		}
+		if next < len(needle) {
+			return withBase(baseTag, tview.Escape(title))
+		}
+	}
+
+	tag := colorTag(color)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && matched[j] == matched[i] {
+			j++
+		}
+		segment := tview.Escape(string(runes[i:j]))
+		if matched[i] {
+			segment = tag + segment + reset
+		}
+		b.WriteString(segment)
+		i = j
+	}
+	return withBase(baseTag, b.String())
+}
+
+// withBase puts text in the color baseTag switches to, if any
+func withBase(baseTag string, text string) string {
+	if baseTag == "" {
+		return text
+	}
+	return baseTag + text + "[-]"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestThemeFromEnv(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		want     theme
+		wantErrs int
+	}{
+		{map[string]string{}, defaultTheme, 0},
+		{map[string]string{"TALIAS_HIGHLIGHT": "teal", "TALIAS_SELECTED": "#ff8800", "TALIAS_BORDER": " Gray "},
+			theme{Highlight: tcell.ColorTeal, Selected: tcell.NewHexColor(0xff8800), Border: tcell.ColorGray}, 0},
+		// Unknown colors are reported and keep the default
+		{map[string]string{"TALIAS_HIGHLIGHT": "not-a-color", "TALIAS_BORDER": "red"},
+			theme{Highlight: defaultTheme.Highlight, Selected: defaultTheme.Selected, Border: tcell.ColorRed}, 1},
+	}
+	for _, tt := range tests {
+		got, errs := themeFromEnv(defaultTheme, func(name string) string { return tt.env[name] })
+		if got.Highlight != tt.want.Highlight || got.Selected != tt.want.Selected || got.Border != tt.want.Border {
+			t.Errorf("themeFromEnv(%v) = %v %v %v, want %v %v %v", tt.env,
+				got.Highlight, got.Selected, got.Border, tt.want.Highlight, tt.want.Selected, tt.want.Border)
+		}
+		if len(errs) != tt.wantErrs {
+			t.Errorf("themeFromEnv(%v) errors = %v, want %d", tt.env, errs, tt.wantErrs)
+		}
+	}
+}
+
+// Defaults, then TALIAS_* variables, then the config's theme, like main applies them
+func TestThemePrecedence(t *testing.T) {
+	env := map[string]string{"TALIAS_HIGHLIGHT": "teal", "TALIAS_BORDER": "gray"}
+	colors, _ := themeFromEnv(defaultTheme, func(name string) string { return env[name] })
+	colors, err := themeConfig{Highlight: "orange"}.apply(colors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  tcell.Color
+		want tcell.Color
+	}{
+		{"highlight from the theme", colors.Highlight, tcell.ColorOrange},
+		{"border from the environment", colors.Border, tcell.ColorGray},
+		{"selected by default", colors.Selected, defaultTheme.Selected},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSelectionText(t *testing.T) {
+	category := displayTitle(Option{Title: "Docker", Children: []Option{{Title: "ps", Command: "docker ps"}}})
+	disabled := displayTitle(Option{Title: "Deploy", Command: "make deploy", Disabled: true})
+	tests := []struct {
+		text     string
+		selected bool
+		style    string
+		want     string
+	}{
+		{category, true, selectionArrow, "▶ > Docker"},
+		{category, false, selectionArrow, "  > Docker"},
+		{disabled, false, selectionArrow, "  [gray]Deploy[-]"},
+		{"Top", true, selectionBoth, "▶ Top"},
+		{"Top", true, selectionHighlight, "Top"},
+		{"Top", false, "", "Top"},
+	}
+	for _, tt := range tests {
+		if got := selectionText(tt.text, tt.selected, tt.style); got != tt.want {
+			t.Errorf("selectionText(%q, %t, %q) = %q, want %q", tt.text, tt.selected, tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestSelectionStyleFromConfig(t *testing.T) {
+	colors, err := themeConfig{Selection: selectionArrow}.apply(defaultTheme)
+	if err != nil || colors.Selection != selectionArrow {
+		t.Errorf("apply() = %q, %v, want the arrow style", colors.Selection, err)
+	}
+	if colors, err := (themeConfig{}).apply(defaultTheme); err != nil || colors.Selection != selectionHighlight {
+		t.Errorf("apply() without a style = %q, %v, want highlight", colors.Selection, err)
+	}
+	if _, err := (themeConfig{Selection: "underline"}).apply(defaultTheme); err == nil {
+		t.Errorf("apply() accepted an unknown selection style")
+	}
+}
+
+func TestLevelColors(t *testing.T) {
+	colors, err := themeConfig{Levels: map[string]string{"warn": "orange"}}.apply(defaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		level  string
+		want   tcell.Color
+		wantOk bool
+	}{
+		{levelInfo, tcell.ColorGreen, true},
+		{levelWarn, tcell.ColorOrange, true},
+		{levelDanger, tcell.ColorRed, true},
+		{"", tcell.ColorDefault, false},
+		{"critical", tcell.ColorDefault, false},
+	}
+	for _, tt := range tests {
+		got, ok := colors.levelColor(tt.level)
+		if ok != tt.wantOk || (ok && got != tt.want) {
+			t.Errorf("levelColor(%q) = %v, %t, want %v, %t", tt.level, got, ok, tt.want, tt.wantOk)
+		}
+	}
+	if defaultTheme.Levels[levelWarn] != tcell.ColorYellow {
+		t.Error("apply() changed the default theme's level colors")
+	}
+	if _, err := (themeConfig{Levels: map[string]string{"critical": "red"}}).apply(defaultTheme); err == nil {
+		t.Error("apply() accepted an unknown level")
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	tests := []struct {
+		option Option
+		text   string
+		want   string
+	}{
+		{Option{Title: "Drop DB", Command: "dropdb app", Level: levelDanger}, "Drop DB", "[#ff0000]Drop DB[-]"},
+		{Option{Title: "Logs", Command: "tail -f log", Level: levelInfo}, "Logs", "[#008000]Logs[-]"},
+		{Option{Title: "Prod", Level: levelWarn, Children: []Option{{Title: "Up", Command: "up"}}}, "> Prod (1)", "[#ffff00]> Prod (1)[-]"},
+		{Option{Title: "Top", Command: "top"}, "Top", "Top"},
+		{Option{Title: "Push", Level: levelDanger}, "[gray]Push[-]", "[gray]Push[-]"},
+	}
+	for _, tt := range tests {
+		if got := defaultTheme.levelText(tt.option, tt.text); got != tt.want {
+			t.Errorf("levelText(%s) = %q, want %q", tt.option.Title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// treeStats summarises the size and shape of a menu tree
+type treeStats struct {
+	Total           int // every option, categories included
+	Leaves          int // options search can run, see flattenOptions
+	Categories      int // options with children
+	MaxDepth        int // deepest level, top-level options are depth 1
+	DuplicateTitles []string
+}
+
+func computeTreeStats(options []Option) treeStats {
+	stats := treeStats{
+		Leaves:          len(flattenOptions(options)),
+		DuplicateTitles: duplicateTitles(options, ""),
+	}
+	walkTree(options, 1, &stats)
+	return stats
+}
+
+// walkTree counts options and categories and tracks the deepest level
+func walkTree(options []Option, depth int, stats *treeStats) {
+	if len(options) > 0 {
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+	}
+	for _, opt := range options {
+		stats.Total++
+		if len(opt.Children) > 0 {
+			stats.Categories++
+			walkTree(opt.Children, depth+1, stats)
+		}
+	}
+}
+
+// printTreeStats writes the stats in a human readable form
+func printTreeStats(w io.Writer, stats treeStats) {
+	fmt.Fprintf(w, "Options:          %d\n", stats.Total)
+	fmt.Fprintf(w, "Leaves:           %d\n", stats.Leaves)
+	fmt.Fprintf(w, "Categories:       %d\n", stats.Categories)
+	fmt.Fprintf(w, "Max depth:        %d\n", stats.MaxDepth)
+	fmt.Fprintf(w, "Duplicate titles: %d\n", len(stats.DuplicateTitles))
+	for _, path := range stats.DuplicateTitles {
+		fmt.Fprintf(w, "  %s\n", path)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestComputeTreeStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    treeStats
+	}{
+		{"empty", nil, treeStats{}},
+		{"flat", []Option{
+			{Title: "Top", Command: "top"},
+			{Title: "Htop", Command: "htop"},
+		}, treeStats{Total: 2, Leaves: 2, MaxDepth: 1}},
+		{"nested", []Option{
+			{Title: "Docker", Children: []Option{
+				{Title: "Ps", Command: "docker ps"},
+				{Title: "Compose", Children: []Option{
+					{Title: "Up", Command: "docker compose up"},
+					{Title: "Down", Command: "docker compose down"},
+					{Title: "Up", Command: "docker compose up -d"},
+				}},
+			}},
+			{Title: "Later", Children: []Option{}},
+			{Title: "Top", Command: "top"},
+		}, treeStats{Total: 8, Leaves: 5, Categories: 2, MaxDepth: 3, DuplicateTitles: []string{"Docker/Compose/Up"}}},
+	}
+	for _, tt := range tests {
+		got := computeTreeStats(tt.options)
+		if got.Total != tt.want.Total || got.Leaves != tt.want.Leaves || got.Categories != tt.want.Categories ||
+			got.MaxDepth != tt.want.MaxDepth || !slices.Equal(got.DuplicateTitles, tt.want.DuplicateTitles) {
+			t.Errorf("%s: computeTreeStats() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrintTreeStats(t *testing.T) {
+	var b bytes.Buffer
+	printTreeStats(&b, treeStats{Total: 8, Leaves: 5, Categories: 2, MaxDepth: 3, DuplicateTitles: []string{"Docker/Compose/Up"}})
+	want := `Options:          8
+Leaves:           5
+Categories:       2
+Max depth:        3
+Duplicate titles: 1
+  Docker/Compose/Up
+`
+	if b.String() != want {
+		t.Errorf("printTreeStats() = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+// twoStepRun is the state of --two-step: the first Enter on a command only
+// shows it, the command runs when it's picked again before anything else is
+// selected
+type twoStepRun struct {
+	pending string
+}
+
+// Confirm reports whether the option at path runs command now. Otherwise the
+// run is pending until the same command is picked again.
+func (r *twoStepRun) Confirm(path string, command string) bool {
+	run := path + "\n" + command
+	if r.pending != run {
+		r.pending = run
+		return false
+	}
+	r.pending = ""
+	return true
+}
+
+// Cancel drops the pending run and reports whether there was one
+func (r *twoStepRun) Cancel() bool {
+	pending := r.pending != ""
+	r.pending = ""
+	return pending
+}
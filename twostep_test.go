@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestTwoStepRun(t *testing.T) {
+	var run twoStepRun
+	steps := []struct {
+		action  string // "enter" or "cancel"
+		path    string
+		command string
+		want    bool
+	}{
+		// The first Enter only shows the command, the second runs it
+		{"enter", "Git/Push", "git push", false},
+		{"enter", "Git/Push", "git push", true},
+		// After running, the next Enter shows it again
+		{"enter", "Git/Push", "git push", false},
+		// Picking another option in between starts over
+		{"enter", "Git/Pull", "git pull", false},
+		{"enter", "Git/Push", "git push", false},
+		// So do edited commands
+		{"enter", "Git/Push", "git push --force", false},
+		// Escape cancels what was pending, once
+		{"cancel", "", "", true},
+		{"cancel", "", "", false},
+		{"enter", "Git/Push", "git push --force", false},
+		{"enter", "Git/Push", "git push --force", true},
+	}
+	for i, step := range steps {
+		var got bool
+		if step.action == "cancel" {
+			got = run.Cancel()
+		} else {
+			got = run.Confirm(step.path, step.command)
+		}
+		if got != step.want {
+			t.Errorf("step %d: %s %s = %t, want %t", i, step.action, step.path, got, step.want)
+		}
+	}
+}
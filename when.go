@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// conditionHolds evaluates an option's when condition: "NAME" holds while the
+// variable is set and not empty, "NAME=value" while it has exactly that value
+func conditionHolds(when string, lookupEnv func(string) (string, bool)) bool {
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return true
+	}
+	name, want, hasValue := strings.Cut(when, "=")
+	value, set := lookupEnv(strings.TrimSpace(name))
+	if hasValue {
+		return set && value == want
+	}
+	return set && value != ""
+}
+
+// filterByCondition drops options whose when condition doesn't hold, along
+// with everything below them
+func filterByCondition(options []Option, lookupEnv func(string) (string, bool)) []Option {
+	var result []Option
+	for _, opt := range options {
+		if !conditionHolds(opt.When, lookupEnv) {
+			continue
+		}
+		if opt.Children != nil {
+			opt.Children = filterByCondition(opt.Children, lookupEnv)
+			if opt.Children == nil {
+				// Keep a category whose options all went away a category
+				opt.Children = []Option{}
+			}
+		}
+		result = append(result, opt)
+	}
+	return result
+}
+
+// conditionStates describes which when conditions in the tree hold, for keys
+// of caches built from the filtered options
+func conditionStates(options []Option, lookupEnv func(string) (string, bool)) string {
+	seen := make(map[string]bool)
+	var states []string
+	var walk func([]Option)
+	walk = func(options []Option) {
+		for _, opt := range options {
+			if opt.When != "" && !seen[opt.When] {
+				seen[opt.When] = true
+				states = append(states, fmt.Sprintf("%s:%t", opt.When, conditionHolds(opt.When, lookupEnv)))
+			}
+			walk(opt.Children)
+		}
+	}
+	walk(options)
+	sort.Strings(states)
+	return strings.Join(states, ",")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConditionHolds(t *testing.T) {
+	env := map[string]string{"TALIAS_PROD": "1", "EMPTY": "", "STAGE": "staging"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	tests := []struct {
+		when string
+		want bool
+	}{
+		{"", true},
+		{"TALIAS_PROD", true},
+		{" TALIAS_PROD ", true},
+		{"EMPTY", false},
+		{"UNSET", false},
+		{"STAGE=staging", true},
+		{"STAGE=prod", false},
+		{"EMPTY=", true},
+		{"UNSET=", false},
+	}
+	for _, tt := range tests {
+		if got := conditionHolds(tt.when, lookupEnv); got != tt.want {
+			t.Errorf("conditionHolds(%q) = %t, want %t", tt.when, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByCondition(t *testing.T) {
+	options := []Option{
+		{Title: "Build", Command: "make"},
+		{Title: "Prod deploy", Command: "make deploy", When: "TALIAS_PROD"},
+		{Title: "Staging", When: "STAGE=staging", Children: []Option{
+			{Title: "Reset", Command: "make reset"},
+			{Title: "Seed", Command: "make seed", When: "SEED"},
+		}},
+		{Title: "Prod", When: "STAGE=prod", Children: []Option{{Title: "Rollback", Command: "make rollback"}}},
+	}
+	tests := []struct {
+		name        string
+		env         map[string]string
+		want        []string
+		wantStaging []string
+	}{
+		{"unset", map[string]string{}, []string{"Build"}, nil},
+		{"ENV", map[string]string{"TALIAS_PROD": "1"}, []string{"Build", "Prod deploy"}, nil},
+		{"ENV=value", map[string]string{"STAGE": "staging"}, []string{"Build", "Staging"}, []string{"Reset"}},
+		{"nested", map[string]string{"STAGE": "staging", "SEED": "yes"}, []string{"Build", "Staging"}, []string{"Reset", "Seed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookupEnv := func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			}
+			got := filterByCondition(options, lookupEnv)
+			if !slices.Equal(titles(got), tt.want) {
+				t.Fatalf("filterByCondition() = %q, want %q", titles(got), tt.want)
+			}
+			if tt.wantStaging != nil && !slices.Equal(titles(got[1].Children), tt.wantStaging) {
+				t.Errorf("Staging = %q, want %q", titles(got[1].Children), tt.wantStaging)
+			}
+		})
+	}
+}